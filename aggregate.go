@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// issueAggregate collects the pull requests that reference an issue.
+type issueAggregate struct {
+	Key            string
+	Status         string
+	StatusCategory string
+	PullRequests   []*github.PullRequest
+}
+
+// aggregatedIssues maps issue keys to the pull requests that reference them
+// during this run.
+var aggregatedIssues = map[string]*issueAggregate{}
+
+func aggregatePullRequest(issueKey string, status string, statusCategory string, pr *github.PullRequest) {
+	agg, ok := aggregatedIssues[issueKey]
+	if !ok {
+		agg = &issueAggregate{
+			Key: issueKey,
+		}
+		aggregatedIssues[issueKey] = agg
+	}
+	agg.Status = status
+	agg.StatusCategory = statusCategory
+
+	for _, other := range agg.PullRequests {
+		if pullRequestLink(other) == pullRequestLink(pr) {
+			return
+		}
+	}
+	agg.PullRequests = append(agg.PullRequests, pr)
+}
+
+// sortedAggregatedIssues returns the aggregated issues sorted by their keys.
+func sortedAggregatedIssues() []*issueAggregate {
+	var result []*issueAggregate
+	for _, agg := range aggregatedIssues {
+		result = append(result, agg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// printDuplicatePullRequests reports the issues that are referenced by several
+// open pull requests. Usually only the most recently updated one is the real
+// work, so the older ones are flagged as stale.
+func printDuplicatePullRequests() {
+	for _, agg := range sortedAggregatedIssues() {
+		var open []*github.PullRequest
+		for _, pr := range agg.PullRequests {
+			if pr.GetState() == "open" {
+				open = append(open, pr)
+			}
+		}
+		if len(open) < 2 {
+			continue
+		}
+
+		sort.Slice(open, func(i, j int) bool {
+			return open[i].GetUpdatedAt().After(open[j].GetUpdatedAt())
+		})
+
+		ruleWarningf(ruleDuplicatePullRequests, "%s is referenced by %d open pull requests:", agg.Key, len(open))
+		for i, pr := range open {
+			stale := ""
+			if i > 0 {
+				stale = " (stale, consider closing)"
+			}
+			klog.Warningf("  %s: %s, updated %s%s", pullRequestLink(pr), pr.GetTitle(), pr.GetUpdatedAt().Format("2006-01-02"), stale)
+		}
+	}
+}
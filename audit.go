@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// auditRemoteLinks checks that the remote links to pull requests on the Jira
+// issues point to existing pull requests. Links to pull requests that don't
+// exist anymore are reported and, if -prune is set, removed.
+func auditRemoteLinks(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client) {
+	jql := *auditJQL
+	if jql == "" {
+		var quoted []string
+		for _, projectKey := range jiraProjects {
+			quoted = append(quoted, fmt.Sprintf("%q", projectKey))
+		}
+		jql = fmt.Sprintf("project in (%s) ORDER BY key", strings.Join(quoted, ", "))
+	}
+
+	klog.V(2).Infof("Auditing remote links of issues matching %q...", jql)
+
+	checked := 0
+	var orphaned []string
+	err := jiraClient.Issue.SearchPages(jql, &jira.SearchOptions{Fields: []string{"summary"}}, func(issue jira.Issue) error {
+		links, resp, err := jiraClient.Issue.GetRemoteLinks(issue.Key)
+		if isPermissionError(resp) {
+			reportPermissionError(issue.Key, "get remote links", err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, link := range *links {
+			if link.Object == nil {
+				continue
+			}
+			owner, repo, number, err := parsePullRequestURL(link.Object.URL)
+			if err != nil {
+				continue
+			}
+
+			checked++
+			_, ghResp, err := githubClient.PullRequests.Get(ctx, owner, repo, number)
+			if ghResp != nil && ghResp.StatusCode == http.StatusNotFound {
+				ruleWarningf(ruleOrphanedLink, "%s: the remote link %s points to a pull request that doesn't exist", issue.Key, link.Object.URL)
+				orphaned = append(orphaned, fmt.Sprintf("%s: %s", issue.Key, link.Object.URL))
+				if *pruneLinks {
+					pruneRemoteLink(jiraClient, issue.Key, link)
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fatal(err)
+	}
+
+	klog.Infof("Audited %d remote links to pull requests, %d of them are orphaned", checked, len(orphaned))
+	for _, item := range orphaned {
+		klog.Infof("  %s", item)
+	}
+	printPermissionErrors()
+	printPlannedChanges()
+}
+
+func pruneRemoteLink(jiraClient *jira.Client, issueKey string, link jira.RemoteLink) {
+	if readOnlyProjects[issueProject(issueKey)] {
+		klog.V(2).Infof("The remote link %s is not removed from %s as the project is read-only", link.Object.URL, issueKey)
+		return
+	}
+
+	if *dryRun {
+		planChange(issueKey, "remote links: - %s", link.Object.URL)
+		return
+	}
+
+	klog.V(1).Infof("Removing the remote link %s from the issue %s...", link.Object.URL, issueKey)
+	resp, err := deleteRemoteLink(jiraClient, issueKey, link.ID)
+	if isPermissionError(resp) {
+		reportPermissionError(issueKey, "delete a remote link", err)
+		return
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
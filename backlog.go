@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// authorBacklog is the review backlog of a single author.
+type authorBacklog struct {
+	Author            string  `json:"author"`
+	Count             int     `json:"count"`
+	OldestAgeSeconds  float64 `json:"oldest_age_seconds"`
+	AverageAgeSeconds float64 `json:"average_age_seconds"`
+
+	totalAge time.Duration
+}
+
+// reviewBacklog maps the authors of the pull requests that are awaiting
+// review to their backlogs.
+var reviewBacklog = map[string]*authorBacklog{}
+
+func recordAwaitingReview(author string, age time.Duration) {
+	b, ok := reviewBacklog[author]
+	if !ok {
+		b = &authorBacklog{Author: author}
+		reviewBacklog[author] = b
+	}
+	b.Count++
+	b.totalAge += age
+	if age.Seconds() > b.OldestAgeSeconds {
+		b.OldestAgeSeconds = age.Seconds()
+	}
+}
+
+// sortedReviewBacklog returns the backlogs with the largest ones first. To
+// keep the output bounded in large organizations, the authors beyond
+// maxAuthors are merged into the "other" entry.
+func sortedReviewBacklog(maxAuthors int) []*authorBacklog {
+	var result []*authorBacklog
+	for _, b := range reviewBacklog {
+		b.AverageAgeSeconds = b.totalAge.Seconds() / float64(b.Count)
+		result = append(result, b)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Author < result[j].Author
+	})
+
+	if maxAuthors <= 0 || len(result) <= maxAuthors {
+		return result
+	}
+
+	other := &authorBacklog{Author: "other"}
+	for _, b := range result[maxAuthors:] {
+		other.Count += b.Count
+		other.totalAge += b.totalAge
+		if b.OldestAgeSeconds > other.OldestAgeSeconds {
+			other.OldestAgeSeconds = b.OldestAgeSeconds
+		}
+	}
+	other.AverageAgeSeconds = other.totalAge.Seconds() / float64(other.Count)
+	return append(result[:maxAuthors], other)
+}
+
+// writeReviewBacklog writes the per-author review backlog as a JSON array.
+func writeReviewBacklog(path string, maxAuthors int) error {
+	backlog := sortedReviewBacklog(maxAuthors)
+	if backlog == nil {
+		backlog = []*authorBacklog{}
+	}
+	buf, err := json.MarshalIndent(backlog, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(buf, '\n'), 0644)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// bootstrap links all pull requests from the repositories to their issues. The
+// pull requests are processed from the oldest to the newest one, so that the
+// pages stay stable and the progress can be resumed from the state file.
+func bootstrap(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, keyRegexp *regexp.Regexp) {
+	st := &state{}
+	if *stateFile != "" {
+		var err error
+		st, err = loadState(*stateFile)
+		if err != nil {
+			fatal(err)
+		}
+	} else {
+		klog.Warning("The -state-file flag is not set, the bootstrap won't be resumable")
+	}
+	if st.Bootstrap == nil {
+		st.Bootstrap = map[string]*bootstrapProgress{}
+	}
+
+	for _, repo := range repositories {
+		if !repo.jiraEnabled() {
+			continue
+		}
+		fullName := repo.Owner + "/" + repo.Name
+
+		progress := st.Bootstrap[fullName]
+		if progress == nil {
+			progress = &bootstrapProgress{NextPage: 1}
+			st.Bootstrap[fullName] = progress
+		}
+		if progress.Done {
+			klog.V(2).Infof("The repository %s is already bootstrapped", fullName)
+			continue
+		}
+
+		for {
+			klog.V(1).Infof("Bootstrapping %s: processing page %d...", fullName, progress.NextPage)
+			prs, resp, err := githubClient.PullRequests.List(ctx, repo.Owner, repo.Name, &github.PullRequestListOptions{
+				State:     "all",
+				Sort:      "created",
+				Direction: "asc",
+				ListOptions: github.ListOptions{
+					Page:    progress.NextPage,
+					PerPage: *perPage,
+				},
+			})
+			if err != nil {
+				fatal(err)
+			}
+
+			checkRenamedRepository(repo, prs)
+
+			linked := 0
+			for _, pr := range prs {
+				if isExcluded(pr) {
+					continue
+				}
+				issueKeys := pullRequestIssueKeys(ctx, githubClient, pr, keyRegexp)
+				if len(issueKeys) == 0 {
+					continue
+				}
+				linkPullRequestToIssues(ctx, jiraClient, githubClient, pr, issueKeys)
+				linked++
+			}
+			klog.V(1).Infof("Bootstrapping %s: page %d had %d pull requests, %d of them reference issues", fullName, progress.NextPage, len(prs), linked)
+
+			if resp.NextPage == 0 {
+				progress.Done = true
+			} else {
+				progress.NextPage = resp.NextPage
+			}
+
+			if *stateFile != "" {
+				if err := st.save(*stateFile); err != nil {
+					fatal(err)
+				}
+			}
+
+			if progress.Done {
+				break
+			}
+		}
+
+		klog.V(1).Infof("The repository %s is bootstrapped", fullName)
+	}
+
+	printPermissionErrors()
+	printPlannedChanges()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// checkTitle prints how the pull request title would be recognized. It
+// returns false if the title doesn't reference an issue nor a bug.
+func checkTitle(title string, keyRegexp, bugRegexp *regexp.Regexp) bool {
+	issueKeys := titleIssueKeys(title, keyRegexp)
+	hasBZ := bugRegexp.MatchString(title)
+	wip := wipRegexp != nil && wipRegexp.MatchString(title)
+
+	if len(issueKeys) > 0 {
+		for _, issueKey := range issueKeys {
+			fmt.Printf("Issue: %s (project %s)\n", issueKey, issueProject(issueKey))
+		}
+	} else {
+		fmt.Println("Issue: none")
+	}
+
+	switch {
+	case wip:
+		fmt.Println("Classification: WIP")
+	case hasBZ:
+		fmt.Println("Classification: bug")
+	case len(issueKeys) > 0:
+		fmt.Println("Classification: feature")
+	default:
+		fmt.Println("Classification: none, the title doesn't reference a bug nor a story")
+	}
+
+	return len(issueKeys) > 0 || hasBZ
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// commitSHARegexp matches abbreviated and full commit SHAs. The matches
+// without digits or letters are ignored as they are likely to be words or
+// numbers.
+var commitSHARegexp = regexp.MustCompile(`\b[0-9a-f]{7,40}\b`)
+
+// commitURLRegexp matches links to commits on GitHub.
+var commitURLRegexp = regexp.MustCompile(`https://github\.com/([^/\s]+)/([^/\s]+)/commit/([0-9a-f]{7,40})\b`)
+
+func looksLikeSHA(s string) bool {
+	return strings.ContainsAny(s, "0123456789") && strings.ContainsAny(s, "abcdef")
+}
+
+// issueCommitSHAs returns the commit SHAs that are mentioned in the
+// description and the comments of the issue.
+func issueCommitSHAs(issue jira.Issue) []string {
+	texts := []string{issue.Fields.Description}
+	if issue.Fields.Comments != nil {
+		for _, comment := range issue.Fields.Comments.Comments {
+			texts = append(texts, comment.Body)
+		}
+	}
+
+	var shas []string
+	for _, text := range texts {
+		for _, match := range commitURLRegexp.FindAllStringSubmatch(text, -1) {
+			if !contains(shas, match[3]) {
+				shas = append(shas, match[3])
+			}
+		}
+		for _, sha := range commitSHARegexp.FindAllString(text, -1) {
+			if looksLikeSHA(sha) && !contains(shas, sha) {
+				shas = append(shas, sha)
+			}
+		}
+	}
+	return shas
+}
+
+// findCommits returns the commits from the configured repositories that match
+// the SHA.
+func findCommits(ctx context.Context, githubClient *github.Client, sha string) []*github.CommitResult {
+	var commits []*github.CommitResult
+	for _, query := range repositoryQueries("hash:" + sha) {
+		found, _, err := githubClient.Search.Commits(ctx, query, nil)
+		if err != nil {
+			klog.Warningf("Unable to search for the commit %s: %v", sha, err)
+			continue
+		}
+		commits = append(commits, found.Commits...)
+	}
+	return commits
+}
+
+// linkCommits creates remote links to the commits from the configured
+// repositories that are mentioned in the issues matching -link-commits-jql,
+// e.g. for changes that are pushed directly without pull requests.
+func linkCommits(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client) {
+	jql := *linkCommitsJQL
+	if jql == "" {
+		var quoted []string
+		for _, projectKey := range jiraProjects {
+			quoted = append(quoted, fmt.Sprintf("%q", projectKey))
+		}
+		jql = fmt.Sprintf("project in (%s) AND updated >= -7d ORDER BY key", strings.Join(quoted, ", "))
+	}
+
+	klog.V(2).Infof("Looking for commits in issues matching %q...", jql)
+
+	err := jiraClient.Issue.SearchPages(jql, &jira.SearchOptions{Fields: []string{"description", "comment"}}, func(issue jira.Issue) error {
+		shas := issueCommitSHAs(issue)
+		if len(shas) == 0 {
+			return nil
+		}
+
+		links, resp, err := jiraClient.Issue.GetRemoteLinks(issue.Key)
+		if isPermissionError(resp) {
+			reportPermissionError(issue.Key, "get remote links", err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, sha := range shas {
+			for _, commit := range findCommits(ctx, githubClient, sha) {
+				linkCommitToIssue(jiraClient, issue.Key, *links, commit)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fatal(err)
+	}
+
+	printPermissionErrors()
+	printPlannedChanges()
+}
+
+func linkCommitToIssue(jiraClient *jira.Client, issueKey string, links []jira.RemoteLink, commit *github.CommitResult) {
+	fullName := commit.GetRepository().GetFullName()
+	remoteURL := fmt.Sprintf("https://github.com/%s/commit/%s", fullName, commit.GetSHA())
+	for _, link := range links {
+		if normalizeURL(link.Object.URL) == normalizeURL(remoteURL) {
+			klog.V(3).Infof("The commit %s is already linked to %s", remoteURL, issueKey)
+			return
+		}
+	}
+
+	if !writeRepos[fullName] {
+		klog.V(2).Infof("The commit %s is not linked to the issue %s as the repository is read-only", remoteURL, issueKey)
+		return
+	}
+	if readOnlyProjects[issueProject(issueKey)] {
+		klog.V(2).Infof("The commit %s is not linked to the issue %s as the project is read-only", remoteURL, issueKey)
+		return
+	}
+
+	message := commit.GetCommit().GetMessage()
+	if i := strings.Index(message, "\n"); i != -1 {
+		message = message[:i]
+	}
+	remoteTitle := fmt.Sprintf("%s@%.7s: %s", fullName, commit.GetSHA(), message)
+
+	if *dryRun {
+		planChange(issueKey, "remote links: + %s (%s)", remoteURL, remoteTitle)
+		return
+	}
+
+	klog.V(1).Infof("Linking the commit %s to the issue %s...", remoteURL, issueKey)
+
+	link := &jira.RemoteLink{
+		Object: &jira.RemoteLinkObject{
+			URL:   remoteURL,
+			Title: remoteTitle,
+		},
+	}
+	if *linkIconURL != "" {
+		link.Object.Icon = &jira.RemoteLinkIcon{
+			Url16x16: *linkIconURL,
+			Title:    "GitHub",
+		}
+	}
+
+	req, _ := jiraClient.NewRequest("POST", "rest/api/2/issue/"+issueKey+"/remotelink", link)
+	resp, err := jiraClient.Do(req, nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if isPermissionError(resp) {
+		reportPermissionError(issueKey, "create a remote link", err)
+		return
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
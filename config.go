@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// config is the configuration file. The fields that are not set in the file
+// keep their built-in defaults.
+//
+// The file may define named profiles, e.g. for different environments. The
+// fields of the selected profile override the top-level ones.
+//
+// String values may reference environment variables as ${VAR}, so that
+// secrets don't have to be stored in the file.
+type config struct {
+	Repositories              []OwnerName         `json:"repositories,omitempty"`
+	JiraProjects              []string            `json:"jiraProjects,omitempty"`
+	Team                      []string            `json:"team,omitempty"`
+	GitHubTeams               []string            `json:"githubTeams,omitempty"`
+	TeamRepos                 []string            `json:"teamRepos,omitempty"`
+	WriteRepos                []string            `json:"writeRepos,omitempty"`
+	ReadOnlyProjects          []string            `json:"readOnlyProjects,omitempty"`
+	WIPPatterns               []string            `json:"wipPatterns,omitempty"`
+	ExcludedTitlePatterns     []string            `json:"excludedTitlePatterns,omitempty"`
+	TitleSeparators           []string            `json:"titleSeparators,omitempty"`
+	BugPatterns               []string            `json:"bugPatterns,omitempty"`
+	ReadyLabels               []string            `json:"readyLabels,omitempty"`
+	CheckRequiredLabels       *bool               `json:"checkRequiredLabels,omitempty"`
+	RequiredLabelPrefixes     []string            `json:"requiredLabelPrefixes,omitempty"`
+	ReviewStatuses            []string            `json:"reviewStatuses,omitempty"`
+	MergedStatuses            []string            `json:"mergedStatuses,omitempty"`
+	ReviewStatusesByType      map[string][]string `json:"reviewStatusesByType,omitempty"`
+	MergedStatusesByType      map[string][]string `json:"mergedStatusesByType,omitempty"`
+	JiraHeaders               map[string]string   `json:"jiraHeaders,omitempty"`
+	LabelMapping              map[string]string   `json:"labelMapping,omitempty"`
+	ComponentMapping          map[string]string   `json:"componentMapping,omitempty"`
+	LinkIcons                 map[string]string   `json:"linkIcons,omitempty"`
+	PriorityMapping           map[string]string   `json:"priorityMapping,omitempty"`
+	PullRequestURLField       *string             `json:"pullRequestURLField,omitempty"`
+	PullRequestURLFieldFormat *string             `json:"pullRequestURLFieldFormat,omitempty"`
+	ProjectStatusMapping      map[string][]string `json:"projectStatusMapping,omitempty"`
+	SprintBoards              map[string]int      `json:"sprintBoards,omitempty"`
+
+	Profiles map[string]*config `json:"profiles,omitempty"`
+}
+
+func isRemoteConfig(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+func readConfig(path string, transport http.RoundTripper) ([]byte, error) {
+	if !isRemoteConfig(path) {
+		return ioutil.ReadFile(path)
+	}
+
+	if err := checkTLS("the config URL", path); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   *configTimeout,
+	}
+	resp, err := client.Get(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch the config from %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch the config from %s: %s", path, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch the config from %s: %w", path, err)
+	}
+	return data, nil
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// loadConfig reads the configuration file from a local path or from an
+// http(s) URL and applies it on top of the built-in defaults. If profile is
+// not empty, the profile is applied on top of the top-level configuration.
+func loadConfig(path string, profile string, transport http.RoundTripper) error {
+	data, err := readConfig(path, transport)
+	if err != nil {
+		return err
+	}
+
+	data, err = expandConfigEnv(data)
+	if err != nil {
+		return fmt.Errorf("unable to parse the config %s: %w", path, err)
+	}
+
+	var cfg config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return fmt.Errorf("unable to parse the config %s: %w", path, err)
+	}
+
+	cfg.apply()
+
+	if profile != "" {
+		p, ok := cfg.Profiles[profile]
+		if !ok || p == nil {
+			return fmt.Errorf("the profile %q is not defined in the config %s", profile, path)
+		}
+		if p.Profiles != nil {
+			return fmt.Errorf("the profile %q in the config %s cannot define profiles", profile, path)
+		}
+		p.apply()
+	}
+
+	return nil
+}
+
+var envReferenceRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandConfigEnv replaces ${VAR} references in the string values of the
+// YAML document with the values of the environment variables. The result is
+// a JSON document, which is also valid YAML.
+func expandConfigEnv(data []byte) ([]byte, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	doc, err = expandEnvValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+func expandEnvValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		var missing []string
+		expanded := envReferenceRegexp.ReplaceAllStringFunc(v, func(ref string) string {
+			name := envReferenceRegexp.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("the environment variable %s is referenced, but not set", missing[0])
+		}
+		return expanded, nil
+	case []interface{}:
+		for i := range v {
+			item, err := expandEnvValue(v[i])
+			if err != nil {
+				return nil, err
+			}
+			v[i] = item
+		}
+	case map[string]interface{}:
+		for key := range v {
+			item, err := expandEnvValue(v[key])
+			if err != nil {
+				return nil, err
+			}
+			v[key] = item
+		}
+	}
+	return value, nil
+}
+
+func sortedSet(set map[string]bool) []string {
+	var values []string
+	for value, ok := range set {
+		if ok {
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// configEnvVars lists the environment variables that configure the tool and
+// whether their values are secret.
+var configEnvVars = []struct {
+	Name   string
+	Secret bool
+}{
+	{"JIRA_BASE_URL", false},
+	{"JIRA_USERNAME", false},
+	{"JIRA_PASSWORD", true},
+	{"JIRA_HEADERS", true},
+	{"GITHUB_TOKEN", true},
+}
+
+// printableConfig returns the effective configuration together with the
+// values of the flags and the environment variables for -print-config. The
+// secrets are redacted.
+func printableConfig() (map[string]interface{}, error) {
+	data, err := json.Marshal(effectiveConfig())
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	flags := map[string]string{}
+	flag.VisitAll(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	result["flags"] = flags
+
+	env := map[string]string{}
+	for _, v := range configEnvVars {
+		value, ok := os.LookupEnv(v.Name)
+		if !ok {
+			continue
+		}
+		if v.Secret && value != "" {
+			value = "REDACTED"
+		}
+		env[v.Name] = value
+	}
+	result["environment"] = env
+
+	return result, nil
+}
+
+// effectiveConfig returns the configuration that is in effect after the
+// built-in defaults, the configuration file and the environment are applied.
+// The values of jiraHeaders are redacted as they usually carry credentials.
+func effectiveConfig() *config {
+	headers := map[string]string{}
+	for name := range jiraHeaders {
+		headers[name] = "REDACTED"
+	}
+	return &config{
+		Repositories:              repositories,
+		JiraProjects:              jiraProjects,
+		Team:                      sortedSet(team),
+		GitHubTeams:               githubTeams,
+		TeamRepos:                 sortedSet(teamRepos),
+		WriteRepos:                sortedSet(writeRepos),
+		ReadOnlyProjects:          sortedSet(readOnlyProjects),
+		WIPPatterns:               wipPatterns,
+		ExcludedTitlePatterns:     excludedTitlePatterns,
+		TitleSeparators:           titleSeparators,
+		BugPatterns:               bugPatterns,
+		ReadyLabels:               readyLabels,
+		CheckRequiredLabels:       &checkRequiredLabels,
+		RequiredLabelPrefixes:     requiredLabelPrefixes,
+		ReviewStatuses:            reviewStatuses,
+		MergedStatuses:            mergedStatuses,
+		ReviewStatusesByType:      reviewStatusesByType,
+		MergedStatusesByType:      mergedStatusesByType,
+		JiraHeaders:               headers,
+		LabelMapping:              labelMapping,
+		ComponentMapping:          componentMapping,
+		LinkIcons:                 linkIcons,
+		PriorityMapping:           priorityMapping,
+		PullRequestURLField:       &pullRequestURLField,
+		PullRequestURLFieldFormat: &pullRequestURLFieldFormat,
+		ProjectStatusMapping:      projectStatusMapping,
+		SprintBoards:              sprintBoards,
+	}
+}
+
+func (cfg *config) apply() {
+	if cfg.Repositories != nil {
+		repositories = cfg.Repositories
+	}
+	if cfg.JiraProjects != nil {
+		jiraProjects = cfg.JiraProjects
+	}
+	if cfg.Team != nil {
+		team = stringSet(cfg.Team)
+	}
+	if cfg.GitHubTeams != nil {
+		githubTeams = cfg.GitHubTeams
+	}
+	if cfg.TeamRepos != nil {
+		teamRepos = stringSet(cfg.TeamRepos)
+	}
+	if cfg.WriteRepos != nil {
+		writeRepos = stringSet(cfg.WriteRepos)
+	}
+	if cfg.ReadOnlyProjects != nil {
+		readOnlyProjects = stringSet(cfg.ReadOnlyProjects)
+	}
+	if cfg.WIPPatterns != nil {
+		wipPatterns = cfg.WIPPatterns
+	}
+	if cfg.ExcludedTitlePatterns != nil {
+		excludedTitlePatterns = cfg.ExcludedTitlePatterns
+	}
+	if cfg.TitleSeparators != nil {
+		titleSeparators = cfg.TitleSeparators
+	}
+	if cfg.BugPatterns != nil {
+		bugPatterns = cfg.BugPatterns
+	}
+	if cfg.ReadyLabels != nil {
+		readyLabels = cfg.ReadyLabels
+	}
+	if cfg.CheckRequiredLabels != nil {
+		checkRequiredLabels = *cfg.CheckRequiredLabels
+	}
+	if cfg.RequiredLabelPrefixes != nil {
+		requiredLabelPrefixes = cfg.RequiredLabelPrefixes
+	}
+	if cfg.ReviewStatuses != nil {
+		reviewStatuses = cfg.ReviewStatuses
+	}
+	if cfg.MergedStatuses != nil {
+		mergedStatuses = cfg.MergedStatuses
+	}
+	if cfg.ReviewStatusesByType != nil {
+		reviewStatusesByType = cfg.ReviewStatusesByType
+	}
+	if cfg.MergedStatusesByType != nil {
+		mergedStatusesByType = cfg.MergedStatusesByType
+	}
+	if cfg.JiraHeaders != nil {
+		jiraHeaders = cfg.JiraHeaders
+	}
+	if cfg.LabelMapping != nil {
+		labelMapping = cfg.LabelMapping
+	}
+	if cfg.ComponentMapping != nil {
+		componentMapping = cfg.ComponentMapping
+	}
+	if cfg.PullRequestURLField != nil {
+		pullRequestURLField = *cfg.PullRequestURLField
+	}
+	if cfg.PullRequestURLFieldFormat != nil {
+		pullRequestURLFieldFormat = *cfg.PullRequestURLFieldFormat
+	}
+	if cfg.PriorityMapping != nil {
+		priorityMapping = cfg.PriorityMapping
+	}
+	if cfg.LinkIcons != nil {
+		linkIcons = cfg.LinkIcons
+	}
+	if cfg.ProjectStatusMapping != nil {
+		projectStatusMapping = cfg.ProjectStatusMapping
+	}
+	if cfg.SprintBoards != nil {
+		sprintBoards = cfg.SprintBoards
+	}
+}
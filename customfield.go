@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// pullRequestURLField is the ID of the custom field, e.g. customfield_10050,
+// that is set to the links to the pull requests of the issue, so that
+// dashboards don't have to parse remote links. Empty disables it.
+var pullRequestURLField = ""
+
+// pullRequestURLFieldFormat is either "single", then the field has the link
+// to the last linked pull request, or "list", then the field has the
+// comma-separated links to all pull requests of the issue.
+var pullRequestURLFieldFormat = "list"
+
+// syncIssueCustomField adds the link to the pull request to
+// pullRequestURLField of the issue.
+func syncIssueCustomField(jiraClient *jira.Client, pr *github.PullRequest, issue *jira.Issue) error {
+	if pullRequestURLField == "" {
+		return nil
+	}
+
+	current, _ := issue.Fields.Unknowns.String(pullRequestURLField)
+	prURL := pullRequestLink(pr)
+
+	want := prURL
+	if pullRequestURLFieldFormat == "list" {
+		var urls []string
+		for _, u := range strings.Split(current, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				urls = append(urls, u)
+			}
+		}
+		for _, u := range urls {
+			if normalizeURL(u) == normalizeURL(prURL) {
+				return nil
+			}
+		}
+		want = strings.Join(append(urls, prURL), ", ")
+	}
+	if want == current {
+		return nil
+	}
+
+	if *dryRun {
+		planChange(issue.Key, "%s: %q → %q", pullRequestURLField, current, want)
+		return nil
+	}
+
+	klog.V(1).Infof("Setting %s of the issue %s to %s...", pullRequestURLField, issue.Key, want)
+
+	resp, err := jiraClient.Issue.UpdateIssue(issue.Key, map[string]interface{}{
+		"fields": map[string]interface{}{
+			pullRequestURLField: want,
+		},
+	})
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if isPermissionError(resp) {
+		reportPermissionError(issue.Key, "set "+pullRequestURLField, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if issue.Fields.Unknowns == nil {
+		issue.Fields.Unknowns = map[string]interface{}{}
+	}
+	issue.Fields.Unknowns[pullRequestURLField] = want
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// stateResultsMaxAge is how long the results that are not seen anymore are
+// kept in the state file if -link-updated-since is not set.
+const stateResultsMaxAge = 30 * 24 * time.Hour
+
+// newLinks collects the remote links that are created during this run for
+// -deltas-only.
+var newLinks []string
+
+func resultKey(r result) string {
+	return r.PullRequestURL + " " + r.IssueKey
+}
+
+// deltaSink compares the results with the results of the previous run from
+// the state file and reports only the changes: new mismatches, resolved
+// mismatches, status changes and new links. The first run reports all
+// mismatches as new.
+type deltaSink struct {
+	path    string
+	w       io.Writer
+	state   *state
+	results []result
+}
+
+func newDeltaSink(path string, w io.Writer) (*deltaSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("-deltas-only requires -state-file")
+	}
+	st, err := loadState(path)
+	if err != nil {
+		return nil, err
+	}
+	return &deltaSink{
+		path:  path,
+		w:     w,
+		state: st,
+	}, nil
+}
+
+func (s *deltaSink) Write(r result) error {
+	s.results = append(s.results, r)
+	return nil
+}
+
+func (s *deltaSink) deltas() []string {
+	var deltas []string
+	for _, r := range s.results {
+		prev, ok := s.state.Results[resultKey(r)]
+		switch {
+		case (!ok || !prev.Mismatch) && r.Mismatch:
+			deltas = append(deltas, fmt.Sprintf("New mismatch: %s is %s, want %s (%s)", r.IssueKey, r.JiraStatus, r.ExpectedStatus, r.PullRequestURL))
+		case ok && prev.Mismatch && !r.Mismatch:
+			deltas = append(deltas, fmt.Sprintf("Resolved: %s is %s (%s)", r.IssueKey, r.JiraStatus, r.PullRequestURL))
+		case ok && prev.JiraStatus != r.JiraStatus:
+			deltas = append(deltas, fmt.Sprintf("Status changed: %s is %s, was %s (%s)", r.IssueKey, r.JiraStatus, prev.JiraStatus, r.PullRequestURL))
+		}
+	}
+	sort.Strings(deltas)
+	for _, link := range newLinks {
+		deltas = append(deltas, "New link: "+link)
+	}
+	return deltas
+}
+
+func (s *deltaSink) Close() error {
+	for _, delta := range s.deltas() {
+		if _, err := fmt.Fprintln(s.w, delta); err != nil {
+			return err
+		}
+	}
+
+	// The state is reloaded as other parts of the state may be updated during
+	// the run, e.g. by -bootstrap. The results that are not seen in this run
+	// are kept until they are older than -link-updated-since, as their pull
+	// requests may be just outside of it, or stateResultsMaxAge.
+	st, err := loadState(s.path)
+	if err != nil {
+		return err
+	}
+	if st.Results == nil {
+		st.Results = map[string]result{}
+	}
+	maxAge := stateResultsMaxAge
+	if *linkUpdatedSince != 0 {
+		maxAge = *linkUpdatedSince
+	}
+	cutoff := runStarted.Add(-maxAge)
+	for key, r := range st.Results {
+		if r.Timestamp.Before(cutoff) {
+			delete(st.Results, key)
+		}
+	}
+	for _, r := range s.results {
+		st.Results[resultKey(r)] = r
+	}
+	return st.save(s.path)
+}
+
+// Abort doesn't report anything and keeps the previous results, so that the
+// changes are reported by the next complete run.
+func (s *deltaSink) Abort(reason string) error {
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/klog/v2"
+)
+
+// plannedChanges collects the changes to Jira issues that are not applied
+// because of -dry-run, so that they can be shown per issue at the end of the
+// run.
+var plannedChanges = map[string][]string{}
+
+func planChange(issueKey string, format string, args ...interface{}) {
+	change := fmt.Sprintf(format, args...)
+	klog.V(1).Infof("Dry run: %s: %s", issueKey, change)
+	plannedChanges[issueKey] = append(plannedChanges[issueKey], change)
+}
+
+func printPlannedChanges() {
+	if !*dryRun {
+		return
+	}
+
+	if len(plannedChanges) == 0 {
+		klog.Infof("Dry run: no changes to Jira issues")
+		return
+	}
+
+	var issueKeys []string
+	for issueKey := range plannedChanges {
+		issueKeys = append(issueKeys, issueKey)
+	}
+	sort.Strings(issueKeys)
+
+	klog.Infof("Dry run: the following changes to %d Jira issues are not applied:", len(issueKeys))
+	for _, issueKey := range issueKeys {
+		klog.Infof("  %s:", issueKey)
+		for _, change := range plannedChanges[issueKey] {
+			klog.Infof("    %s", change)
+		}
+	}
+}
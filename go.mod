@@ -5,5 +5,8 @@ go 1.15
 require (
 	github.com/andygrunwald/go-jira v1.12.0
 	github.com/google/go-github/v32 v32.1.0
+	github.com/mattn/go-sqlite3 v1.14.5
+	github.com/nats-io/nats.go v1.11.0
 	k8s.io/klog/v2 v2.3.0
+	sigs.k8s.io/yaml v1.2.0
 )
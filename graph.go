@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+)
+
+func pullRequestGraphColor(pr *github.PullRequest) string {
+	switch {
+	case isMerged(pr):
+		return "purple"
+	case pr.GetState() == "closed":
+		return "gray"
+	default:
+		return "blue"
+	}
+}
+
+func issueGraphColor(statusCategory string) string {
+	switch statusCategory {
+	case jira.StatusCategoryComplete:
+		return "green"
+	case jira.StatusCategoryInProgress:
+		return "yellow"
+	case jira.StatusCategoryToDo:
+		return "lightblue"
+	default:
+		return "white"
+	}
+}
+
+// writeGraph writes the DOT graph of the aggregated issues and the pull
+// requests that reference them.
+func writeGraph(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "digraph links {")
+	fmt.Fprintln(w, "\trankdir=LR;")
+	fmt.Fprintln(w, "\tnode [style=filled];")
+
+	prs := map[string]bool{}
+	for _, agg := range sortedAggregatedIssues() {
+		fmt.Fprintf(w, "\t%q [shape=box, fillcolor=%s, label=%q];\n", agg.Key, issueGraphColor(agg.StatusCategory), agg.Key+"\n"+agg.Status)
+		for _, pr := range agg.PullRequests {
+			id := pullRequestLinkTitle(pr)
+			if !prs[id] {
+				fmt.Fprintf(w, "\t%q [shape=ellipse, color=%s, fillcolor=white, URL=%q];\n", id, pullRequestGraphColor(pr), pullRequestLink(pr))
+				prs[id] = true
+			}
+			fmt.Fprintf(w, "\t%q -> %q;\n", id, agg.Key)
+		}
+	}
+
+	fmt.Fprintln(w, "}")
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"github.com/andygrunwald/go-jira"
+	"k8s.io/klog/v2"
+)
+
+// linkIcons maps the states of pull requests (open, draft, merged and closed)
+// to the icons for their remote links, so that the list of links on the issue
+// shows the states. The states that are not mapped use -link-icon-url. The
+// configuration can replace the map, an empty map keeps the icons of the
+// existing links.
+var linkIcons = map[string]string{
+	"open":   "https://raw.githubusercontent.com/primer/octicons/main/icons/git-pull-request-16.svg",
+	"draft":  "https://raw.githubusercontent.com/primer/octicons/main/icons/git-pull-request-draft-16.svg",
+	"merged": "https://raw.githubusercontent.com/primer/octicons/main/icons/git-merge-16.svg",
+	"closed": "https://raw.githubusercontent.com/primer/octicons/main/icons/git-pull-request-closed-16.svg",
+}
+
+func linkIconState(state pullRequestState) string {
+	if state == pullRequestClosedUnmerged {
+		return "closed"
+	}
+	return state.String()
+}
+
+// remoteLinkIcon returns the icon for remote links to pull requests in the
+// state or nil if the links shouldn't have an icon.
+func remoteLinkIcon(state pullRequestState) *jira.RemoteLinkIcon {
+	iconURL, ok := linkIcons[linkIconState(state)]
+	if !ok {
+		iconURL = *linkIconURL
+	}
+	if iconURL == "" {
+		return nil
+	}
+	return &jira.RemoteLinkIcon{
+		Url16x16: iconURL,
+		Title:    "GitHub",
+	}
+}
+
+// updateRemoteLinkIcon updates the icon of the existing remote link when the
+// state of the pull request changes. Only the icons from linkIcons are
+// updated, so that links with the default icon are not rewritten.
+func updateRemoteLinkIcon(jiraClient *jira.Client, issueKey string, link jira.RemoteLink, state pullRequestState) error {
+	if len(linkIcons) == 0 {
+		return nil
+	}
+
+	icon := remoteLinkIcon(state)
+	current := ""
+	if link.Object.Icon != nil {
+		current = link.Object.Icon.Url16x16
+	}
+	if icon == nil || icon.Url16x16 == current {
+		return nil
+	}
+
+	if *dryRun {
+		planChange(issueKey, "remote links: %s: icon %s → %s", link.Object.URL, current, icon.Url16x16)
+		return nil
+	}
+
+	klog.V(1).Infof("Updating the icon of the remote link %s on the issue %s...", link.Object.URL, issueKey)
+	link.Object.Icon = icon
+	resp, err := updateRemoteLink(jiraClient, issueKey, link)
+	if isPermissionError(resp) {
+		reportPermissionError(issueKey, "update a remote link", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+var issueKeyRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-[0-9]+$`)
+
+// parsePullRequestURL parses URLs like https://github.com/owner/repo/pull/123.
+func parsePullRequestURL(rawURL string) (owner string, repo string, number int, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host != "github.com" || len(parts) != 4 || parts[2] != "pull" {
+		return "", "", 0, fmt.Errorf("%s is not a pull request URL", rawURL)
+	}
+
+	number, err = strconv.Atoi(parts[3])
+	if err != nil {
+		return "", "", 0, fmt.Errorf("%s is not a pull request URL: %w", rawURL, err)
+	}
+
+	return parts[0], parts[1], number, nil
+}
+
+// importCSV links pull requests to issues according to the CSV file with
+// rows of pr_url,issue_key.
+func importCSV(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		fatal(err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+
+	succeeded := 0
+	var skipped, failures []string
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		prURL, issueKey := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if line == 1 && prURL == "pr_url" && issueKey == "issue_key" {
+			continue
+		}
+
+		if !issueKeyRegexp.MatchString(issueKey) {
+			failures = append(failures, fmt.Sprintf("line %d: invalid issue key %q", line, issueKey))
+			continue
+		}
+
+		owner, repo, number, err := parsePullRequestURL(prURL)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		pr, _, err := githubClient.PullRequests.Get(ctx, owner, repo, number)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("line %d: unable to get %s: %v", line, prURL, err))
+			continue
+		}
+
+		permissionErrorsBefore := len(permissionErrors)
+		err = linkPullRequestToIssue(ctx, jiraClient, githubClient, pr, issueKey)
+		switch {
+		case errors.Is(err, errLinkSkipped):
+			skipped = append(skipped, fmt.Sprintf("line %d: %s → %s: %v", line, prURL, issueKey, err))
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("line %d: %v", line, err))
+		case len(permissionErrors) > permissionErrorsBefore:
+			failures = append(failures, fmt.Sprintf("line %d: insufficient permissions for %s", line, issueKey))
+		default:
+			succeeded++
+		}
+	}
+
+	klog.Infof("Imported %d pull requests from %s, %d skipped, %d failed", succeeded, path, len(skipped), len(failures))
+	for _, item := range skipped {
+		klog.Infof("Skipped: %s", item)
+	}
+	for _, failure := range failures {
+		klog.Warningf("Failed to import: %s", failure)
+	}
+	printPermissionErrors()
+	printPlannedChanges()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// issueCache holds the issues that are already fetched during the run.
+var issueCache = map[string]*jira.Issue{}
+
+// pullRequestIssues is a pull request with the keys of the issues that it
+// references.
+type pullRequestIssues struct {
+	pr        *github.PullRequest
+	issueKeys []string
+}
+
+// needChangelog returns true if the issues should be fetched with their
+// changelogs.
+func needChangelog() bool {
+	return *maxReviewTime != 0 || *reopenedGracePeriod != 0
+}
+
+// getIssue gets the issue from issueCache or from Jira. Issues that have just
+// been created may be not found until Jira indexes them, so issues from the
+// Jira projects that are not found are retried according to
+// -not-found-retries.
+func getIssue(jiraClient *jira.Client, issueKey string) (*jira.Issue, *jira.Response, error) {
+	if issue, ok := issueCache[issueKey]; ok {
+		return issue, nil, nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		var opts *jira.GetQueryOptions
+		if needChangelog() {
+			opts = &jira.GetQueryOptions{Expand: "changelog"}
+		}
+		issue, resp, err := jiraClient.Issue.Get(issueKey, opts)
+		if err == nil {
+			issueCache[issueKey] = issue
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound || attempt >= *notFoundRetries || !contains(jiraProjects, issueProject(issueKey)) {
+			return issue, resp, err
+		}
+
+		klog.V(2).Infof("The issue %s is not found, retrying in %s...", issueKey, *notFoundRetryDelay)
+		time.Sleep(*notFoundRetryDelay)
+	}
+}
+
+// prefetchIssuesByKey fetches the issues that are not in issueCache yet using
+// JQL queries like "key in (IR-1, IR-2)". Failures are not fatal as the issues
+// are fetched one by one later anyway.
+func prefetchIssuesByKey(jiraClient *jira.Client, issueKeys []string) {
+	const batchSize = 100
+
+	var missing []string
+	for _, issueKey := range issueKeys {
+		if _, ok := issueCache[issueKey]; !ok && !contains(missing, issueKey) {
+			missing = append(missing, issueKey)
+		}
+	}
+
+	for len(missing) > 0 {
+		batch := missing
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		missing = missing[len(batch):]
+
+		klog.V(2).Infof("Fetching %d issues...", len(batch))
+		jql := "key in (" + strings.Join(batch, ", ") + ")"
+		opts := &jira.SearchOptions{
+			MaxResults: batchSize,
+			Fields:     []string{"*all"},
+			// Nonexistent keys shouldn't fail the whole query.
+			ValidateQuery: "warn",
+		}
+		if needChangelog() {
+			opts.Expand = "changelog"
+		}
+		err := jiraClient.Issue.SearchPages(jql, opts, func(issue jira.Issue) error {
+			issueCache[issue.Key] = &issue
+			return nil
+		})
+		if err != nil {
+			klog.Warningf("Unable to fetch the issues %s: %v", strings.Join(batch, ", "), err)
+		}
+	}
+}
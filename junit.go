@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSink writes the results as a JUnit XML report once all of them are
+// collected, so that CI systems can show them as test results. Each pull
+// request or each issue, depending on the granularity, is a test case that
+// fails if the status of the issue doesn't match.
+type junitSink struct {
+	f           *os.File
+	granularity string
+	results     []result
+}
+
+func newJUnitSink(path string, granularity string) (*junitSink, error) {
+	if granularity != "pr" && granularity != "issue" {
+		return nil, fmt.Errorf("invalid JUnit granularity %q, want pr or issue", granularity)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &junitSink{
+		f:           f,
+		granularity: granularity,
+	}, nil
+}
+
+func (s *junitSink) Write(r result) error {
+	s.results = append(s.results, r)
+	return nil
+}
+
+func (s *junitSink) testCases() []junitTestCase {
+	if s.granularity == "pr" {
+		var testCases []junitTestCase
+		for _, r := range s.results {
+			tc := junitTestCase{
+				ClassName: r.Repo,
+				Name:      fmt.Sprintf("%s %s", r.PullRequestURL, r.IssueKey),
+			}
+			if r.Mismatch {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("%s is %s, want %s", r.IssueKey, r.JiraStatus, r.ExpectedStatus),
+				}
+			}
+			testCases = append(testCases, tc)
+		}
+		return testCases
+	}
+
+	byIssue := map[string]*junitTestCase{}
+	var issueKeys []string
+	for _, r := range s.results {
+		tc, ok := byIssue[r.IssueKey]
+		if !ok {
+			tc = &junitTestCase{
+				ClassName: issueProject(r.IssueKey),
+				Name:      r.IssueKey,
+			}
+			byIssue[r.IssueKey] = tc
+			issueKeys = append(issueKeys, r.IssueKey)
+		}
+		if r.Mismatch {
+			if tc.Failure == nil {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("%s is %s, want %s", r.IssueKey, r.JiraStatus, r.ExpectedStatus),
+				}
+			}
+			tc.Failure.Text += fmt.Sprintf("%s expects %s\n", r.PullRequestURL, r.ExpectedStatus)
+		}
+	}
+	sort.Strings(issueKeys)
+
+	var testCases []junitTestCase
+	for _, issueKey := range issueKeys {
+		testCases = append(testCases, *byIssue[issueKey])
+	}
+	return testCases
+}
+
+func (s *junitSink) write(systemOut string) error {
+	suite := junitTestSuite{
+		Name:      "github-jira-integration",
+		Timestamp: runStarted.UTC().Format("2006-01-02T15:04:05"),
+		TestCases: s.testCases(),
+		SystemOut: systemOut,
+	}
+	suite.Tests = len(suite.TestCases)
+	for _, tc := range suite.TestCases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	if _, err := s.f.WriteString(xml.Header); err != nil {
+		s.f.Close()
+		return err
+	}
+	enc := xml.NewEncoder(s.f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		s.f.Close()
+		return err
+	}
+	if _, err := s.f.WriteString("\n"); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
+
+func (s *junitSink) Close() error {
+	return s.write("")
+}
+
+func (s *junitSink) Abort(reason string) error {
+	return s.write("The results are partial: " + reason)
+}
@@ -1,21 +1,152 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"regexp"
 	"strings"
+	"syscall"
+	"text/template"
+	"time"
 
 	"github.com/andygrunwald/go-jira"
 	"github.com/google/go-github/v32/github"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
 )
 
+var (
+	perPage                 = flag.Int("per-page", 100, "the number of pull requests per page to fetch from GitHub (at most 100)")
+	maxPages                = flag.Int("max-pages", 1, "the maximum number of pages of pull requests to fetch per repository (0 means no limit)")
+	remindersMode           = flag.Bool("reminders", true, "report the state of open pull requests that belong to the team")
+	linkMode                = flag.Bool("link", true, "link pull requests to Jira issues")
+	linkUpdatedSince        = flag.Duration("link-updated-since", 0, "link only pull requests that were updated within this duration (0 means no limit)")
+	minReviewAge            = flag.Duration("min-review-age", 0, "report pull requests as awaiting review only after they have been open for this duration")
+	commentOnMismatch       = flag.Bool("comment-on-mismatch", false, "comment on pull requests from writeRepos when the statuses of their Jira issues are not the expected ones (requires GITHUB_TOKEN)")
+	projectStatus           = flag.Bool("project-status", false, "compare the status of pull requests on GitHub Projects with the status of their Jira issues (requires GITHUB_TOKEN)")
+	projectStatusField      = flag.String("project-status-field", "Status", "the name of the GitHub Projects field that holds the status")
+	linkIconURL             = flag.String("link-icon-url", "https://github.com/favicon.ico", "the icon for remote links to pull requests (empty to omit the icon)")
+	awaitingReviewFormat    = flag.String("awaiting-review-template", `{{if .Assignees}}Awaiting review from {{join .Assignees ", "}}{{else}}ACTION REQUIRED: Review{{end}}: {{.URL}}: {{.Title}}{{if .CI}} (CI: {{.CI}}){{end}}`, "the Go template for the lines about pull requests that are awaiting review, .Assignees is empty if nobody is assigned to review")
+	informationalBranches   = flag.String("informational-branches", "", "a regular expression for base branches (e.g. ^release-) whose pull requests are linked, but don't drive the status of issues")
+	reviewCIStatus          = flag.Bool("review-ci-status", false, "include the CI status into the lines about pull requests that are awaiting review")
+	trackingIssue           = flag.String("tracking-issue", "", "update the list of pull requests awaiting review in the body of this GitHub issue (owner/repo#number, requires GITHUB_TOKEN)")
+	noJiraLabel             = flag.String("no-jira-label", "no-jira", "the label for pull requests that are not expected to have a bug or a story")
+	reportPath              = flag.String("report", "", "write the results to this file")
+	reportFormat            = flag.String("report-format", "json", "the format of the report: json or ndjson")
+	sortResults             = flag.Bool("sort-results", false, "log the findings about pull requests and pass the results to the report and other sinks at the end of the run sorted by repository and pull request number, so that the output of different runs can be compared")
+	subtasks                = flag.String("subtasks", "check", "how to check the status of sub-tasks: check, skip or parent")
+	unlinkClosedUnmerged    = flag.Bool("unlink-closed-unmerged", false, "remove remote links to pull requests that are closed without being merged")
+	linkIssues              = flag.Bool("link-issues", false, "link Jira issues together when a pull request references several of them")
+	issueLinkType           = flag.String("issue-link-type", "Relates", "the type of links between Jira issues that are referenced by the same pull request")
+	mergedAcceptDone        = flag.Bool("merged-accept-done-category", true, "accept any status from the done category for issues of merged pull requests")
+	keyPosition             = flag.String("key-position", "prefix", "where titles reference issues: prefix (\"IR-123: ...\") or anywhere (\"... (IR-123)\")")
+	branchKeyPattern        = flag.String("branch-key-pattern", "", "a regular expression with a group for the issue key (e.g. ^([A-Z]+-[0-9]+)) to find issues in branch names when titles don't reference them")
+	keysFromComments        = flag.Bool("keys-from-comments", false, "look for issue keys in the comments on pull requests whose titles and branches don't reference issues")
+	bootstrapMode           = flag.Bool("bootstrap", false, "link pull requests from the entire history of the repositories instead of the recently updated ones")
+	stateFile               = flag.String("state-file", "", "the file to persist the state between runs, e.g. the progress of -bootstrap")
+	checkTitleValue         = flag.String("check-title", "", "check how the pull request title would be recognized and exit")
+	auditMode               = flag.Bool("audit", false, "check that remote links on Jira issues point to existing pull requests instead of scanning repositories")
+	auditJQL                = flag.String("audit-jql", "", "the JQL query for the issues to audit (default: all issues from the Jira projects)")
+	pruneLinks              = flag.Bool("prune", false, "remove remote links to pull requests that don't exist when auditing")
+	importCSVPath           = flag.String("import-csv", "", "link pull requests to issues from the CSV file with rows of pr_url,issue_key instead of scanning repositories")
+	userAgent               = flag.String("user-agent", "github-jira-integration/"+version, "the User-Agent header for requests to GitHub and Jira")
+	printVersion            = flag.Bool("version", false, "print the version and exit")
+	configPath              = flag.String("config", "", "the configuration file, either a local path or an http(s) URL")
+	configProfile           = flag.String("profile", "", "the profile from the configuration file to use")
+	configTimeout           = flag.Duration("config-timeout", 30*time.Second, "the timeout for fetching the configuration from a URL")
+	maxRuntime              = flag.Duration("max-runtime", 0, "stop processing new pull requests after this duration (0 means no limit)")
+	resultWebhook           = flag.String("result-webhook", "", "post the results as JSON to this URL after the run")
+	resultWebhookType       = flag.String("result-webhook-content-type", "application/json", "the content type for posting the results to -result-webhook")
+	resultWebhookRetries    = flag.Int("result-webhook-retries", 3, "the number of retries for posting the results to -result-webhook")
+	graphPath               = flag.String("graph", "", "write the Graphviz DOT graph of the links between pull requests and issues to this file")
+	fromStdin               = flag.Bool("stdin", false, "link the pull requests listed on stdin as owner/repo#number, one per line, instead of scanning repositories")
+	notFoundRetries         = flag.Int("not-found-retries", 0, "the number of retries for getting issues from the Jira projects that are not found, e.g. because they are not indexed yet")
+	notFoundRetryDelay      = flag.Duration("not-found-retry-delay", 2*time.Second, "the delay between retries for getting issues that are not found")
+	linkDiffStats           = flag.Bool("link-diff-stats", false, "include the numbers of additions, deletions and changed files into the titles of remote links")
+	prefetchIssues          = flag.Bool("prefetch-issues", true, "fetch the issues that are referenced by the pull requests of a repository in bulk using JQL")
+	rewriteRenamedLinks     = flag.Bool("rewrite-renamed-links", false, "rewrite remote links to pull requests from renamed repositories to use the new names")
+	caFile                  = flag.String("ca-file", "", "a PEM file with additional CA certificates to trust for requests to GitHub, Jira, the configuration URL and the result webhook")
+	httpTimeout             = flag.Duration("http-timeout", 0, "the timeout for requests to GitHub, Jira and the result webhook (0 means no timeout)")
+	milestone               = flag.String("milestone", "", "process only pull requests with this milestone")
+	reportDuplicates        = flag.Bool("report-duplicates", false, "report issues that are referenced by several open pull requests and flag the older ones as stale")
+	maxReviewTime           = flag.Duration("max-review-time", 0, "warn about issues that have been in a review status for longer than this duration (0 means no limit)")
+	reconcileIssueKey       = flag.String("reconcile-issue", "", "check the Jira issue against all pull requests that reference it instead of scanning repositories")
+	junitPath               = flag.String("junit", "", "write the results as a JUnit XML report to this file")
+	junitGranularity        = flag.String("junit-granularity", "pr", "the test cases of the JUnit report: pr (a pull request and its issue) or issue (an issue and all its pull requests)")
+	holdLabel               = flag.String("hold-label", "do-not-merge/hold", "the label for pull requests that are on hold")
+	holdWithDoneIssue       = flag.String("hold-with-done-issue", "warn", "what to do about pull requests on hold whose issues are already done: warn or ignore")
+	requireTLS              = flag.Bool("require-tls", true, "refuse to send credentials to Jira and GitHub over plain http")
+	mergedStatusWindow      = flag.Duration("merged-status-window", 0, "check the status of issues only for pull requests that were merged within this duration (0 means no limit)")
+	runIDValue              = flag.String("run-id", "", "the correlation ID of the run for logs, results and requests to GitHub and Jira (default: a random UUID)")
+	reopenedGracePeriod     = flag.Duration("reopened-grace-period", 0, "don't warn about merged pull requests of issues that were reopened within this duration")
+	printConfig             = flag.Bool("print-config", false, "print the effective configuration, flags and environment with redacted secrets and exit")
+	linkCommitsMode         = flag.Bool("link-commits", false, "link commits that are mentioned in Jira issues instead of scanning repositories")
+	linkCommitsJQL          = flag.String("link-commits-jql", "", "the JQL query for the issues to look for commits in (default: the issues from the Jira projects updated within a week)")
+	reviewNudgeAfter        = flag.Duration("review-nudge-after", 0, "report pull requests with requested reviewers that haven't got any reviews after this duration (0 disables it)")
+	requireFixVersion       = flag.Bool("require-fix-version", false, "warn about issues of merged pull requests that don't have a fix version")
+	deltasOnly              = flag.Bool("deltas-only", false, "print only the changes since the previous run, e.g. new mismatches and new links, and log the regular findings only at -v=4 (requires -state-file)")
+	checkSprint             = flag.Bool("check-sprint", false, "warn about issues of open pull requests that aren't in the active sprint of their board (see sprintBoards in the config)")
+	reviewBacklogPath       = flag.String("review-backlog", "", "write per-author counts and ages of pull requests awaiting review as JSON to this file")
+	reviewBacklogMaxAuthors = flag.Int("review-backlog-max-authors", 100, "the maximum number of authors in -review-backlog, the remaining ones are grouped as \"other\"; 0 means no limit")
+	dryRun                  = flag.Bool("dry-run", false, "don't change Jira issues, show the changes that would be made instead")
+	commentMarkerName       = flag.String("comment-marker", "github-jira-integration", "the name for the hidden marker in comments created by this tool (deployments that share repositories need distinct names)")
+)
+
+// commentMarker is embedded into the comments created by this tool, so that
+// they can be found and updated instead of creating new ones. It's set from
+// -comment-marker.
+var commentMarker = "<!-- github-jira-integration -->"
+
 type OwnerName struct {
-	Owner string
-	Name  string
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+
+	// Projects lists the Jira projects that pull requests from this
+	// repository are expected to reference. Empty means any project.
+	Projects []string `json:"projects,omitempty"`
+
+	// PathRules associate the pull requests that change files in
+	// specific directories with Jira projects, e.g. in monorepos.
+	PathRules []pathRule `json:"pathRules,omitempty"`
+
+	// MergedStatuses overrides mergedStatuses for pull requests from this
+	// repository.
+	MergedStatuses []string `json:"mergedStatuses,omitempty"`
+
+	// DefaultProject is the Jira project that bare references like "#123: "
+	// or "123: " in pull request titles are expanded to.
+	DefaultProject string `json:"defaultProject,omitempty"`
+
+	// Jira set to false excludes the repository from all Jira interactions,
+	// its pull requests are only used for the GitHub reminders.
+	Jira *bool `json:"jira,omitempty"`
+}
+
+// jiraEnabled returns whether pull requests from the repository may interact
+// with Jira.
+func (repo OwnerName) jiraEnabled() bool {
+	return repo.Jira == nil || *repo.Jira
+}
+
+// repositoryConfig returns the configuration for the repository or nil if the
+// repository is not configured.
+func repositoryConfig(fullName string) *OwnerName {
+	if oldName, ok := renamedRepos[fullName]; ok {
+		fullName = oldName
+	}
+	for i, repo := range repositories {
+		if strings.EqualFold(repo.Owner+"/"+repo.Name, fullName) {
+			return &repositories[i]
+		}
+	}
+	return nil
 }
 
 var repositories = []OwnerName{
@@ -39,11 +170,155 @@ var team = map[string]bool{
 	"ricardomaraschini": true,
 }
 
+// githubTeams lists GitHub teams (as org/slug) whose members are considered to
+// be members of the team in addition to the ones listed in team.
+var githubTeams = []string{}
+
 var teamRepos = map[string]bool{
 	"openshift/cluster-image-registry-operator": true,
 	"openshift/image-registry":                  true,
 }
 
+// readOnlyProjects lists the Jira projects whose issues are checked, but not
+// linked to pull requests, e.g. because their links are managed by another
+// tool.
+var readOnlyProjects = map[string]bool{}
+
+// writeRepos lists the repositories whose pull requests are allowed to modify
+// Jira issues. Pull requests from other repositories are only analyzed.
+var writeRepos = map[string]bool{
+	"openshift/cluster-image-registry-operator": true,
+	"openshift/image-registry":                  true,
+}
+
+// checkRequiredLabels enables the warnings about team pull requests that don't
+// have labels with requiredLabelPrefixes.
+var checkRequiredLabels = false
+
+var requiredLabelPrefixes = []string{"kind/", "sig/"}
+
+// readyLabels lists the labels that a pull request must have to be considered
+// ready for review. Open pull requests without them are expected to be in
+// progress.
+var readyLabels = []string{}
+
+// wipPatterns are regular expressions for the markers of pull requests that
+// are work in progress. They are matched as whole tokens in the title.
+var wipPatterns = []string{`WIP`, `\[WIP\]`, `Draft:`, `DNM`}
+
+// excludedTitlePatterns are regular expressions for the titles of pull
+// requests that are created by automation and are skipped entirely.
+var excludedTitlePatterns = []string{
+	`^Automatic merge from`,
+	`OWNERS sync`,
+	`^Updating .* images to be consistent with ART`,
+}
+
+// titleSeparators are regular expressions for the separators between the
+// issue references and the rest of pull request titles, e.g. `：` for the
+// full-width colon.
+var titleSeparators = []string{`: `}
+
+// bugPatterns are regular expressions for the references to bugs at the
+// start of pull request titles. They are followed by one of titleSeparators.
+var bugPatterns = []string{`Bug [0-9]+`}
+
+// reviewStatuses lists the names of the status that Jira issues should be in
+// while their pull requests are being reviewed.
+var reviewStatuses = []string{"Code Review", "In Review", "Review"}
+
+// reviewStatusesByType overrides reviewStatuses for issues of the given types,
+// e.g. {"RFE": {"Under Discussion"}}.
+var reviewStatusesByType = map[string][]string{}
+
+// jiraHeaders are added to every request to Jira, e.g. for authentication
+// proxies. Additional headers can be set using the JIRA_HEADERS environment
+// variable as a comma-separated list of name=value pairs.
+var jiraHeaders = map[string]string{}
+
+// mergedStatuses lists the statuses that are acceptable for Jira issues once
+// their pull requests are merged. See also -merged-accept-done-category.
+var mergedStatuses = []string{"On QA", "Done"}
+
+// mergedStatusesByType overrides mergedStatuses for issues of the given types.
+// The per-repository MergedStatuses take precedence over it.
+var mergedStatusesByType = map[string][]string{}
+
+// labelMapping maps GitHub pull request labels to Jira labels that should be
+// added to the linked issue.
+var labelMapping = map[string]string{
+	"kind/bug": "github-bug",
+}
+
+// componentMapping maps GitHub repositories (owner/name) to Jira components
+// that should be added to the linked issue.
+var componentMapping = map[string]string{}
+
+// githubTokenTransport is an http.RoundTripper that authenticates requests
+// to GitHub using a personal access token.
+type githubTokenTransport struct {
+	Token string
+
+	// Transport is the underlying HTTP transport to use when making
+	// requests. It will default to http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+func (t *githubTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+t.Token)
+	return t.transport().RoundTrip(req)
+}
+
+func (t *githubTokenTransport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+func (t *githubTokenTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// headerTransport is an http.RoundTripper that adds static headers to
+// requests.
+type headerTransport struct {
+	Headers map[string]string
+
+	// Transport is the underlying HTTP transport to use when making
+	// requests. It will default to http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range t.Headers {
+		req.Header.Set(name, value)
+	}
+	return t.transport().RoundTrip(req)
+}
+
+func (t *headerTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// parseHeaders parses a comma-separated list of name=value pairs.
+func parseHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid header %q, want name=value", pair)
+		}
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return headers, nil
+}
+
 func getEnv(name string) string {
 	value := os.Getenv(name)
 	if value == "" {
@@ -60,6 +335,37 @@ func pullRequestLinkTitle(pr *github.PullRequest) string {
 	return fmt.Sprintf("%s#%d", pr.Base.Repo.GetFullName(), pr.GetNumber())
 }
 
+// pullRequestHeadRef returns the name of the head branch of the pull request.
+// The head of pull requests from deleted forks may be unknown, in which case
+// it returns an empty string. The base branch is not used instead as its name
+// may reference issues that the pull request has nothing to do with.
+func pullRequestHeadRef(pr *github.PullRequest) string {
+	return pr.GetHead().GetRef()
+}
+
+// normalizeURL returns a canonical form of the URL, so that URLs that differ
+// only in the case of the host, the scheme, a trailing slash, the query or the
+// fragment are equal. The ".git" suffix of the repository and the "/files"
+// tab of the pull request are dropped as well.
+func normalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.RawPath = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.Path = strings.TrimSuffix(u.Path, "/files")
+	u.Path = strings.TrimSuffix(u.Path, ".git")
+	u.Path = strings.Replace(u.Path, ".git/pull/", "/pull/", 1)
+	return u.String()
+}
+
 func pullRequestLabels(pr *github.PullRequest) []string {
 	var labels []string
 	for _, label := range pr.Labels {
@@ -77,197 +383,1668 @@ func contains(labels []string, name string) bool {
 	return false
 }
 
-func linkPullRequestToIssue(jiraClient *jira.Client, pr *github.PullRequest, issueKey string) {
+// warnedIssues tracks the issues that already got a status warning during this
+// run, so that issues referenced by several pull requests are reported once.
+var warnedIssues = map[string]bool{}
+
+// warnedFixVersions prevents warning about the same issue without a fix
+// version for each of its pull requests.
+var warnedFixVersions = map[string]bool{}
+
+// openPullRequestsWithDoneIssues collects open pull requests whose issues are
+// already done, so that they can be summarized at the end of the run.
+var openPullRequestsWithDoneIssues []string
+
+func hasLabelWithPrefix(labels []string, prefix string) bool {
+	for _, label := range labels {
+		if strings.HasPrefix(label, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(labels []string, names []string) bool {
+	for _, name := range names {
+		if !contains(labels, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// skipJira returns an errLinkSkipped error if the pull request must not
+// interact with Jira because it's excluded or Jira is disabled for its
+// repository.
+func skipJira(pr *github.PullRequest) error {
+	if isExcluded(pr) {
+		return fmt.Errorf("the pull request is excluded: %w", errLinkSkipped)
+	}
+	if repo := repositoryConfig(pr.Base.Repo.GetFullName()); repo != nil && !repo.jiraEnabled() {
+		klog.V(2).Infof("Jira is disabled for %s, skipping the pull request %s", pr.Base.Repo.GetFullName(), pullRequestLink(pr))
+		return fmt.Errorf("Jira is disabled for the repository: %w", errLinkSkipped)
+	}
+	return nil
+}
+
+// errLinkSkipped is returned by linkPullRequestToIssue if the pull request is
+// not linked because it's excluded or read-only.
+var errLinkSkipped = errors.New("skipped")
+
+// errIssueNotFound is returned by linkPullRequestToIssue if the referenced
+// issue doesn't exist.
+var errIssueNotFound = errors.New("the issue doesn't exist")
+
+// isFatalLinkError returns true if the error from linkPullRequestToIssue
+// should stop the run. Skipped pull requests and missing issues are already
+// reported.
+func isFatalLinkError(err error) bool {
+	return err != nil && !errors.Is(err, errLinkSkipped) && !errors.Is(err, errIssueNotFound)
+}
+
+// linkPullRequestToIssue checks the status of the issue against the pull
+// request and links them. Permission errors are reported and don't cause an
+// error.
+func linkPullRequestToIssue(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, pr *github.PullRequest, issueKey string) error {
+	if err := skipJira(pr); err != nil {
+		return err
+	}
 	klog.V(3).Infof("Checking if %s is linked to %s...", pullRequestLinkTitle(pr), issueKey)
 
+	// Titles may reference several issues, e.g. "IR-1/OCPBUGS-2: ...". If the
+	// keys may be anywhere, the title is kept as is.
 	title := pr.GetTitle()
-	if strings.HasPrefix(title, issueKey+": ") {
-		title = title[len(issueKey+": "):]
+	if *keyPosition == "prefix" {
+		if loc := titleSeparatorRegexp.FindStringIndex(title); loc != nil && contains(strings.Split(title[:loc[0]], "/"), issueKey) {
+			title = title[loc[1]:]
+		} else if match := bareKeyRegexp.FindStringSubmatch(title); match != nil && issueProject(issueKey)+"-"+match[2] == issueKey {
+			title = title[len(match[0]):]
+		}
 	}
 
-	issue, _, err := jiraClient.Issue.Get(issueKey, nil)
+	issue, resp, err := getIssue(jiraClient, issueKey)
+	if isPermissionError(resp) {
+		reportPermissionError(issueKey, "get the issue", err)
+		return nil
+	}
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		ruleWarningf(ruleNonexistentIssue, "The pull request %s references the issue %s, which doesn't exist", pullRequestLink(pr), issueKey)
+		return fmt.Errorf("%s: %w", issueKey, errIssueNotFound)
+	}
 	if err != nil {
-		klog.Fatal(err)
+		return fmt.Errorf("unable to get the issue %s: %w", issueKey, err)
+	}
+
+	// The status of sub-tasks may be driven by their parents.
+	statusIssue := issue
+	statusKey, status, statusCategory, issueType := issueKey, issue.Fields.Status.Name, issue.Fields.Status.StatusCategory.Key, issue.Fields.Type.Name
+	skipSubtask := false
+	if issue.Fields.Type.Subtask {
+		switch *subtasks {
+		case "skip":
+			skipSubtask = true
+		case "parent":
+			if issue.Fields.Parent != nil {
+				parent, resp, err := getIssue(jiraClient, issue.Fields.Parent.Key)
+				if isPermissionError(resp) {
+					reportPermissionError(issue.Fields.Parent.Key, "get the parent issue", err)
+					skipSubtask = true
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("unable to get the parent issue %s: %w", issue.Fields.Parent.Key, err)
+				}
+				klog.V(3).Infof("%s is a sub-task, checking the status of its parent %s", issueKey, parent.Key)
+				statusIssue = parent
+				statusKey, status, statusCategory, issueType = parent.Key, parent.Fields.Status.Name, parent.Fields.Status.StatusCategory.Key, parent.Fields.Type.Name
+			}
+		}
+	}
+
+	readOnly := !writeRepos[pr.Base.Repo.GetFullName()]
+
+	if !readOnly && !readOnlyProjects[issueProject(issueKey)] {
+		if err := syncIssueLabels(jiraClient, pr, issue); err != nil {
+			return err
+		}
+		if err := syncIssueComponent(jiraClient, pr, issue); err != nil {
+			return err
+		}
+		if err := syncPathRuleComponents(ctx, jiraClient, githubClient, pr, issue); err != nil {
+			return err
+		}
+		if err := syncIssuePriority(jiraClient, pr, issue); err != nil {
+			return err
+		}
+		if err := syncIssueCustomField(jiraClient, pr, issue); err != nil {
+			return err
+		}
 	}
 
-	status := issue.Fields.Status.Name
+	aggregatePullRequest(issueKey, issue.Fields.Status.Name, issue.Fields.Status.StatusCategory.Key, pr)
+
+	if pr.GetState() == "open" && issue.Fields.Status.StatusCategory.Key == jira.StatusCategoryComplete {
+		ruleWarningf(ruleOpenWithDoneIssue, "ATTENTION: The pull request %s is open, but the issue %s is already %s", pullRequestLink(pr), issueKey, issue.Fields.Status.Name)
+		openPullRequestsWithDoneIssues = append(openPullRequestsWithDoneIssues, fmt.Sprintf("%s (%s is %s)", pullRequestLink(pr), issueKey, issue.Fields.Status.Name))
+
+		// The hold label says that the work is explicitly blocked, so the
+		// issue is unlikely to be done.
+		if *holdWithDoneIssue == "warn" && contains(pullRequestLabels(pr), *holdLabel) {
+			ruleWarningf(ruleHoldWithDoneIssue, "The pull request %s is on hold, but the issue %s is already %s", pullRequestLink(pr), issueKey, issue.Fields.Status.Name)
+		}
+	}
 
-	switch pr.GetState() {
-	case "open":
+	var want []string
+	var mismatchRule string
+	acceptDone := false
+	prState := classifyPullRequest(pr)
+	switch {
+	case informationalBranchesRegexp != nil && informationalBranchesRegexp.MatchString(pr.Base.GetRef()):
+		klog.V(2).Infof("The pull request %s targets the informational branch %s, skipping status checks for %s", pullRequestLinkTitle(pr), pr.Base.GetRef(), issueKey)
+	case skipSubtask:
+		klog.V(2).Infof("%s is a sub-task, skipping status checks", issueKey)
+	case prState == pullRequestOpen || prState == pullRequestDraft:
 		labels := pullRequestLabels(pr)
-		if !contains(labels, "do-not-merge/hold") {
-			klog.V(1).Infof("The pull request %s is open and it's not on hold. Please make sure that it has got all approvals or put it on hold.", pullRequestLink(pr))
+		if !contains(labels, *holdLabel) {
+			ruleInfof(1, ruleNotOnHold, "The pull request %s is open and it's not on hold. Please make sure that it has got all approvals or put it on hold.", pullRequestLink(pr))
 		}
 
-		if strings.Contains(title, "WIP") {
-			if status != "In Progress" {
-				klog.V(1).Infof("%s: got %s, want In Progress", issueKey, status)
+		if *checkSprint {
+			if err := checkActiveSprint(jiraClient, pr, statusKey); err != nil {
+				return err
 			}
+		}
+
+		if isWorkInProgress(pr) || !containsAll(labels, readyLabels) {
+			want = []string{"In Progress"}
+			mismatchRule = ruleStatusMismatchInProgress
 		} else {
-			if status != "Code Review" {
-				klog.V(1).Infof("%s: got %s, want Code Review", issueKey, status)
+			want = reviewStatuses
+			mismatchRule = ruleStatusMismatchReview
+			if statuses, ok := reviewStatusesByType[issueType]; ok {
+				want = statuses
 			}
 		}
-	case "closed":
-		if pr.GetMerged() && status != "On QA" && status != "Done" {
-			klog.V(1).Infof("%s: got %s, want On QA or Done", issueKey, status)
+	case prState == pullRequestMerged && *mergedStatusWindow != 0 && time.Since(pr.GetMergedAt()) > *mergedStatusWindow:
+		klog.V(3).Infof("The pull request %s was merged %s, skipping status checks for %s", pullRequestLinkTitle(pr), pr.GetMergedAt().Format("2006-01-02"), issueKey)
+	case prState == pullRequestMerged:
+		if isWorkInProgress(pr) {
+			ruleWarningf(ruleMergedWIP, "The merged pull request %s is still marked as work in progress: %s", pullRequestLink(pr), pr.GetTitle())
 		}
-	default:
-		klog.Warningf("%s: unexpected state %q", pullRequestLink(pr), pr.GetState())
+
+		want = mergedStatuses
+		if statuses, ok := mergedStatusesByType[issueType]; ok {
+			want = statuses
+		}
+		if repo := repositoryConfig(pr.Base.Repo.GetFullName()); repo != nil && len(repo.MergedStatuses) > 0 {
+			want = repo.MergedStatuses
+		}
+		acceptDone = *mergedAcceptDone
+		mismatchRule = ruleStatusMismatchMerged
+
+		if *requireFixVersion && len(statusIssue.Fields.FixVersions) == 0 && !warnedFixVersions[statusKey] {
+			ruleWarningf(ruleMissingFixVersion, "The pull request %s is merged, but the issue %s doesn't have a fix version", pullRequestLink(pr), statusKey)
+			warnedFixVersions[statusKey] = true
+		}
+	case prState == pullRequestClosedUnmerged:
+		// Abandoned pull requests don't say anything about the issue.
+	}
+
+	if *projectStatus {
+		checkProjectStatus(ctx, githubClient, pr, statusKey, status)
+	}
+
+	mismatch := len(want) > 0 && !contains(want, status) && !(acceptDone && statusCategory == jira.StatusCategoryComplete)
+
+	if len(want) > 0 {
+		recordResult(result{
+			Repo:           pr.Base.Repo.GetFullName(),
+			PullRequestURL: pullRequestLink(pr),
+			IssueKey:       statusKey,
+			JiraStatus:     status,
+			ExpectedStatus: strings.Join(want, " or "),
+			Mismatch:       mismatch,
+		})
+	}
+
+	// Reopened issues are being reworked, so their merged pull requests are
+	// expected to be followed by new ones.
+	reworked := mismatch && mismatchRule == ruleStatusMismatchMerged && *reopenedGracePeriod != 0 && recentlyReopened(jiraClient, statusIssue)
+
+	if mismatch && !reworked {
+		if !warnedIssues[statusKey] {
+			ruleInfof(1, mismatchRule, "%s: got %s, want %s", statusKey, status, strings.Join(want, " or "))
+			warnedIssues[statusKey] = true
+		}
+		if *commentOnMismatch {
+			recordStatusMismatch(pr, statusKey, status, want)
+		}
+	} else if !mismatch && mismatchRule == ruleStatusMismatchReview && *maxReviewTime != 0 {
+		checkStuckInReview(statusIssue)
 	}
 
-	links, _, err := jiraClient.Issue.GetRemoteLinks(issueKey)
+	links, resp, err := jiraClient.Issue.GetRemoteLinks(issueKey)
+	if isPermissionError(resp) {
+		reportPermissionError(issueKey, "get remote links", err)
+		return nil
+	}
 	if err != nil {
-		klog.Fatal(err)
+		return fmt.Errorf("unable to get remote links of %s: %w", issueKey, err)
 	}
 
 	remoteURL := pullRequestLink(pr)
 	remoteTitle := fmt.Sprintf("%s: %s", pullRequestLinkTitle(pr), title)
 
+	if *unlinkClosedUnmerged && prState == pullRequestClosedUnmerged {
+		return unlinkPullRequestFromIssue(jiraClient, pr, issueKey, *links, readOnly)
+	}
+
+	oldURL := oldPullRequestLink(pr)
 	for _, link := range *links {
-		if link.Object.URL == remoteURL {
+		if normalizeURL(link.Object.URL) == normalizeURL(remoteURL) {
 			klog.V(3).Infof("%s is already linked to %s", pullRequestLinkTitle(pr), issueKey)
-			return
+			if !readOnly && !readOnlyProjects[issueProject(issueKey)] {
+				return updateRemoteLinkIcon(jiraClient, issueKey, link, prState)
+			}
+			return nil
+		}
+		if oldURL != "" && normalizeURL(link.Object.URL) == normalizeURL(oldURL) {
+			if *rewriteRenamedLinks && !readOnly && !readOnlyProjects[issueProject(issueKey)] {
+				return rewriteRemoteLink(jiraClient, issueKey, link, remoteURL, remoteTitle)
+			}
+			klog.V(2).Infof("%s is linked to %s using the old name of the repository", pullRequestLinkTitle(pr), issueKey)
+			return nil
 		}
 	}
 
+	if readOnly {
+		klog.V(2).Infof("The pull request %s is not linked to the issue %s as the repository is read-only", pullRequestLinkTitle(pr), issueKey)
+		return fmt.Errorf("the repository is read-only: %w", errLinkSkipped)
+	}
+	if readOnlyProjects[issueProject(issueKey)] {
+		klog.V(2).Infof("The pull request %s is not linked to the issue %s as the project is read-only", pullRequestLinkTitle(pr), issueKey)
+		return fmt.Errorf("the project is read-only: %w", errLinkSkipped)
+	}
+
+	if *linkDiffStats {
+		remoteTitle += " " + pullRequestDiffStats(ctx, githubClient, pr)
+	}
+
+	if *dryRun {
+		planChange(issueKey, "remote links: + %s (%s)", remoteURL, remoteTitle)
+		return nil
+	}
+
 	klog.V(1).Infof("Linking the pull request %s to the issue %s...", pullRequestLinkTitle(pr), issueKey)
 
 	link := &jira.RemoteLink{
 		Object: &jira.RemoteLinkObject{
 			URL:   remoteURL,
 			Title: remoteTitle,
-			Icon: &jira.RemoteLinkIcon{
-				Url16x16: "https://github.com/favicon.ico",
-				Title:    "GitHub",
-			},
+			Icon:  remoteLinkIcon(prState),
 		},
 	}
 
 	req, _ := jiraClient.NewRequest("POST", "rest/api/2/issue/"+issueKey+"/remotelink", link)
-	resp, err := jiraClient.Do(req, nil)
+	resp, err = jiraClient.Do(req, nil)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
+	if isPermissionError(resp) {
+		reportPermissionError(issueKey, "create a remote link", err)
+		return nil
+	}
 	if err != nil {
-		klog.Fatal(err)
+		return fmt.Errorf("unable to link %s to %s: %w", pullRequestLink(pr), issueKey, err)
 	}
+	newLinks = append(newLinks, fmt.Sprintf("%s → %s", pullRequestLink(pr), issueKey))
+	return nil
 }
 
-func printPullRequestState(pr *github.PullRequest, hasJiraStory bool, hasBZ bool) {
-	if strings.Contains(pr.GetTitle(), "WIP") {
-		return
+// pullRequestDiffStats returns the size of the pull request, e.g.
+// "(+10 -2, 3 files)". The pull requests from the list API don't have the
+// stats, so they are fetched individually when needed.
+func pullRequestDiffStats(ctx context.Context, githubClient *github.Client, pr *github.PullRequest) string {
+	if pr.ChangedFiles == nil {
+		full, _, err := githubClient.PullRequests.Get(ctx, pr.Base.Repo.GetOwner().GetLogin(), pr.Base.Repo.GetName(), pr.GetNumber())
+		if err != nil {
+			fatal(err)
+		}
+		pr.Additions, pr.Deletions, pr.ChangedFiles = full.Additions, full.Deletions, full.ChangedFiles
 	}
 
-	if !hasJiraStory && !hasBZ {
-		klog.V(1).Infof("The pull request %s is not assigned to a bug nor a story: %s", pullRequestLink(pr), pr.GetTitle())
+	files := "files"
+	if pr.GetChangedFiles() == 1 {
+		files = "file"
 	}
+	return fmt.Sprintf("(+%d -%d, %d %s)", pr.GetAdditions(), pr.GetDeletions(), pr.GetChangedFiles(), files)
+}
 
-	labels := map[string]bool{}
-	for _, label := range pr.Labels {
-		labels[label.GetName()] = true
+// linkPullRequestToIssues links the pull request to each of the issues and,
+// if requested, links the issues together.
+func linkPullRequestToIssues(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, pr *github.PullRequest, issueKeys []string) {
+	errs := linkPullRequestToIssueKeys(ctx, jiraClient, githubClient, pr, issueKeys)
+	for _, issueKey := range issueKeys {
+		if err := errs[issueKey]; isFatalLinkError(err) {
+			fatal(err)
+		}
 	}
+}
 
-	if labels["approved"] && labels["lgtm"] {
-		if labels["do-not-merge/hold"] {
-			klog.V(1).Infof("ACTION REQUIRED: Unhold: %s: %s", pullRequestLink(pr), pr.GetTitle())
-			return
+// linkPullRequestToIssueKeys is like linkPullRequestToIssues, but returns the
+// errors for the issues that are not processed instead of exiting.
+func linkPullRequestToIssueKeys(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, pr *github.PullRequest, issueKeys []string) map[string]error {
+	errs := map[string]error{}
+	if err := skipJira(pr); err != nil {
+		for _, issueKey := range issueKeys {
+			errs[issueKey] = err
 		}
-		if pr.Base.GetRef() != "master" && !labels["cherry-pick-approved"] {
-			klog.V(1).Infof("Awaiting cherry-pick-approved: %s: %s", pullRequestLink(pr), pr.GetTitle())
-			return
+		return errs
+	}
+	checkIssueProjects(pr, issueKeys)
+	checkPathRules(ctx, githubClient, pr, issueKeys)
+	complete := true
+	permissionErrorsBefore := len(permissionErrors)
+	for _, issueKey := range issueKeys {
+		if err := linkPullRequestToIssue(ctx, jiraClient, githubClient, pr, issueKey); err != nil {
+			errs[issueKey] = err
+			complete = complete && !isFatalLinkError(err)
 		}
-		klog.V(1).Infof("Approved: %s: %s", pullRequestLink(pr), pr.GetTitle())
+	}
+	// The comment is about all issues of the pull request, so it's not
+	// touched if some of them couldn't be checked.
+	complete = complete && len(permissionErrors) == permissionErrorsBefore
+	if *commentOnMismatch && complete && writeRepos[pr.Base.Repo.GetFullName()] {
+		syncStatusComment(ctx, jiraClient, githubClient, pr)
+	}
+	if *linkIssues && len(issueKeys) > 1 {
+		linkIssuesTogether(jiraClient, pr, issueKeys)
+	}
+	return errs
+}
+
+// checkIssueProjects warns about issues that are not from the projects of the
+// repository, as they are likely to be copy-pasted from somewhere else.
+func checkIssueProjects(pr *github.PullRequest, issueKeys []string) {
+	repo := repositoryConfig(pr.Base.Repo.GetFullName())
+	if repo == nil || len(repo.Projects) == 0 {
 		return
 	}
 
-	var assignees []string
-	for _, user := range pr.Assignees {
-		assignees = append(assignees, user.GetLogin())
+	for _, issueKey := range issueKeys {
+		project := issueProject(issueKey)
+		if contains(repo.Projects, project) {
+			continue
+		}
+		suggestion := repo.Projects[0] + strings.TrimPrefix(issueKey, project)
+		ruleWarningf(ruleWrongProject, "The pull request %s references %s, but the repository %s uses the projects %s. Did you mean %s?", pullRequestLink(pr), issueKey, pr.Base.Repo.GetFullName(), strings.Join(repo.Projects, ", "), suggestion)
 	}
-	if len(assignees) > 0 {
-		klog.V(1).Infof("Awaiting review from %s: %s: %s", strings.Join(assignees, ", "), pullRequestLink(pr), pr.GetTitle())
+}
+
+// linkIssuesTogether creates Jira issue links between the first issue and the
+// other issues that are referenced by the pull request.
+func linkIssuesTogether(jiraClient *jira.Client, pr *github.PullRequest, issueKeys []string) {
+	if !writeRepos[pr.Base.Repo.GetFullName()] {
+		klog.V(2).Infof("The issues %s are not linked together as the repository of %s is read-only", strings.Join(issueKeys, ", "), pullRequestLinkTitle(pr))
 		return
 	}
 
-	klog.V(1).Infof("ACTION REQUIRED: Review: %s: %s", pullRequestLink(pr), pr.GetTitle())
-}
+	issue, resp, err := jiraClient.Issue.Get(issueKeys[0], nil)
+	if isPermissionError(resp) {
+		reportPermissionError(issueKeys[0], "get the issue", err)
+		return
+	}
+	if err != nil {
+		fatal(err)
+	}
 
-func assignedToTeam(pr *github.PullRequest) bool {
-	for _, user := range pr.Assignees {
-		if team[user.GetLogin()] {
-			return true
+	linked := map[string]bool{}
+	for _, link := range issue.Fields.IssueLinks {
+		if link.InwardIssue != nil {
+			linked[link.InwardIssue.Key] = true
+		}
+		if link.OutwardIssue != nil {
+			linked[link.OutwardIssue.Key] = true
 		}
 	}
-	return false
-}
 
-func main() {
-	klog.InitFlags(nil)
-	flag.Parse()
+	for _, otherKey := range issueKeys[1:] {
+		if otherKey == issue.Key || linked[otherKey] {
+			continue
+		}
 
-	baseURL := getEnv("JIRA_BASE_URL")
-	tp := jira.BasicAuthTransport{
-		Username: getEnv("JIRA_USERNAME"),
-		Password: getEnv("JIRA_PASSWORD"),
+		if *dryRun {
+			planChange(issue.Key, "issue links: + %s %s", *issueLinkType, otherKey)
+			linked[otherKey] = true
+			continue
+		}
+
+		klog.V(1).Infof("Linking the issue %s to the issue %s (%s)...", issue.Key, otherKey, *issueLinkType)
+		resp, err := jiraClient.Issue.AddLink(&jira.IssueLink{
+			Type: jira.IssueLinkType{
+				Name: *issueLinkType,
+			},
+			OutwardIssue: &jira.Issue{Key: issue.Key},
+			InwardIssue:  &jira.Issue{Key: otherKey},
+		})
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if isPermissionError(resp) {
+			reportPermissionError(issue.Key, "link it to "+otherKey, err)
+			continue
+		}
+		if err != nil {
+			fatal(err)
+		}
+		linked[otherKey] = true
 	}
+}
 
-	keyPattern := `(?:`
-	for i, projectKey := range jiraProjects {
-		if i != 0 {
-			keyPattern += `|`
+// unlinkPullRequestFromIssue removes the remote links to the pull request from
+// the issue.
+func unlinkPullRequestFromIssue(jiraClient *jira.Client, pr *github.PullRequest, issueKey string, links []jira.RemoteLink, readOnly bool) error {
+	remoteURL := pullRequestLink(pr)
+	for _, link := range links {
+		if normalizeURL(link.Object.URL) != normalizeURL(remoteURL) {
+			continue
+		}
+
+		if readOnly {
+			klog.V(2).Infof("The closed pull request %s is not unlinked from the issue %s as the repository is read-only", pullRequestLinkTitle(pr), issueKey)
+			return nil
+		}
+		if readOnlyProjects[issueProject(issueKey)] {
+			klog.V(2).Infof("The closed pull request %s is not unlinked from the issue %s as the project is read-only", pullRequestLinkTitle(pr), issueKey)
+			return nil
+		}
+
+		if *dryRun {
+			planChange(issueKey, "remote links: - %s", link.Object.URL)
+			continue
+		}
+
+		klog.V(1).Infof("Unlinking the closed pull request %s from the issue %s...", pullRequestLinkTitle(pr), issueKey)
+
+		resp, err := deleteRemoteLink(jiraClient, issueKey, link.ID)
+		if isPermissionError(resp) {
+			reportPermissionError(issueKey, "delete a remote link", err)
+			return nil
+		}
+		if err != nil {
+			return err
 		}
-		keyPattern += regexp.QuoteMeta(projectKey)
 	}
-	keyPattern += `)-[0-9]+`
-	keyRegexp, err := regexp.Compile(`(` + keyPattern + `): `)
+	return nil
+}
+
+func deleteRemoteLink(jiraClient *jira.Client, issueKey string, linkID int) (*jira.Response, error) {
+	req, err := jiraClient.NewRequest("DELETE", fmt.Sprintf("rest/api/2/issue/%s/remotelink/%d", issueKey, linkID), nil)
 	if err != nil {
-		klog.Fatal(err)
+		return nil, err
+	}
+	resp, err := jiraClient.Do(req, nil)
+	if resp != nil {
+		resp.Body.Close()
 	}
+	return resp, err
+}
 
-	bugRegexp, err := regexp.Compile(`Bug [0-9]+: `)
+// updateRemoteLink replaces the object of the existing remote link.
+func updateRemoteLink(jiraClient *jira.Client, issueKey string, link jira.RemoteLink) (*jira.Response, error) {
+	req, err := jiraClient.NewRequest("PUT", fmt.Sprintf("rest/api/2/issue/%s/remotelink/%d", issueKey, link.ID), &jira.RemoteLink{
+		Object: link.Object,
+	})
 	if err != nil {
-		klog.Fatal(err)
+		return nil, err
 	}
+	resp, err := jiraClient.Do(req, nil)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
 
-	ctx := context.Background()
-
-	jiraClient, err := jira.NewClient(tp.Client(), baseURL)
+// checkTLS returns an error if -require-tls is set and the URL is not https,
+// as the credentials would be sent in plain text.
+func checkTLS(name string, rawURL string) error {
+	if !*requireTLS {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		klog.Fatal(err)
+		return fmt.Errorf("unable to parse %s: %w", name, err)
 	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("%s should be an https URL, got %q (use -require-tls=false to allow it)", name, rawURL)
+	}
+	return nil
+}
 
-	githubClient := github.NewClient(nil)
+func issueLink(jiraClient *jira.Client, issueKey string) string {
+	baseURL := jiraClient.GetBaseURL()
+	return strings.TrimSuffix(baseURL.String(), "/") + "/browse/" + issueKey
+}
 
-	for _, repo := range repositories {
-		klog.V(2).Infof("Analyzing github repository %s/%s...", repo.Owner, repo.Name)
-		prs, _, err := githubClient.PullRequests.List(ctx, repo.Owner, repo.Name, &github.PullRequestListOptions{
-			State:     "all",
-			Sort:      "updated",
-			Direction: "desc",
-			ListOptions: github.ListOptions{
-				Page:    1,
-				PerPage: 100,
-			},
-		})
+// findStatusComment returns the bot comment on the pull request that is
+// created by syncStatusComment or nil if there is none.
+func findStatusComment(ctx context.Context, githubClient *github.Client, pr *github.PullRequest) *github.IssueComment {
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		comments, resp, err := githubClient.Issues.ListComments(ctx, pr.Base.Repo.GetOwner().GetLogin(), pr.Base.Repo.GetName(), pr.GetNumber(), opts)
 		if err != nil {
-			klog.Fatal(err)
+			fatal(err)
+		}
+		for _, comment := range comments {
+			if strings.Contains(comment.GetBody(), commentMarker) {
+				return comment
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil
 		}
+		opts.Page = resp.NextPage
+	}
+}
 
-		for _, pr := range prs {
-			match := keyRegexp.FindStringSubmatch(pr.GetTitle())
+// statusMismatch is an issue whose status doesn't match its pull request.
+type statusMismatch struct {
+	IssueKey string
+	Status   string
+	Want     []string
+}
 
-			if pr.GetState() == "open" && (team[pr.User.GetLogin()] || assignedToTeam(pr) || teamRepos[pr.Base.Repo.GetFullName()]) {
-				hasJiraStory := match != nil
-				hasBZ := bugRegexp.MatchString(pr.GetTitle())
-				printPullRequestState(pr, hasJiraStory, hasBZ)
-			}
+// statusMismatches collects the status mismatches by pull request URL for
+// -comment-on-mismatch, so that each pull request gets a single comment about
+// all its issues.
+var statusMismatches = map[string][]statusMismatch{}
 
-			if match == nil {
-				continue
-			}
-			issueKey := match[1]
-			linkPullRequestToIssue(jiraClient, pr, issueKey)
+func recordStatusMismatch(pr *github.PullRequest, issueKey string, status string, want []string) {
+	url := pullRequestLink(pr)
+	for _, m := range statusMismatches[url] {
+		if m.IssueKey == issueKey {
+			return
+		}
+	}
+	statusMismatches[url] = append(statusMismatches[url], statusMismatch{
+		IssueKey: issueKey,
+		Status:   status,
+		Want:     want,
+	})
+}
+
+// syncStatusComment creates or updates the bot comment on the pull request
+// that explains why the statuses of its Jira issues are not the expected ones.
+// The comment is found by commentMarker, so it's updated in place on
+// subsequent runs, and it's deleted once all issues are in sync.
+func syncStatusComment(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, pr *github.PullRequest) {
+	owner := pr.Base.Repo.GetOwner().GetLogin()
+	repo := pr.Base.Repo.GetName()
+
+	mismatches := statusMismatches[pullRequestLink(pr)]
+	existing := findStatusComment(ctx, githubClient, pr)
+
+	if len(mismatches) == 0 {
+		if existing == nil {
+			return
+		}
+		klog.V(1).Infof("Deleting the comment on the pull request %s as the statuses of its issues are resolved...", pullRequestLinkTitle(pr))
+		_, err := githubClient.Issues.DeleteComment(ctx, owner, repo, existing.GetID())
+		if err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	var buf strings.Builder
+	buf.WriteString(commentMarker + "\nThe statuses of the Jira issues don't match the state of this pull request:\n\n")
+	for _, m := range mismatches {
+		fmt.Fprintf(&buf, "* [%s](%s) is in the status **%s**, but this pull request expects it to be **%s**.\n",
+			m.IssueKey, issueLink(jiraClient, m.IssueKey), m.Status, strings.Join(m.Want, "** or **"))
+	}
+	buf.WriteString("\nPlease move the issues to the expected statuses.\n")
+	body := buf.String()
+
+	if existing == nil {
+		klog.V(1).Infof("Commenting on the pull request %s about the statuses of its issues...", pullRequestLinkTitle(pr))
+		_, _, err := githubClient.Issues.CreateComment(ctx, owner, repo, pr.GetNumber(), &github.IssueComment{
+			Body: github.String(body),
+		})
+		if err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if existing.GetBody() == body {
+		klog.V(3).Infof("The comment on %s is up to date", pullRequestLinkTitle(pr))
+		return
+	}
+
+	klog.V(1).Infof("Updating the comment on the pull request %s about the statuses of its issues...", pullRequestLinkTitle(pr))
+	_, _, err := githubClient.Issues.EditComment(ctx, owner, repo, existing.GetID(), &github.IssueComment{
+		Body: github.String(body),
+	})
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func syncIssueLabels(jiraClient *jira.Client, pr *github.PullRequest, issue *jira.Issue) error {
+	var missing []string
+	for _, label := range pullRequestLabels(pr) {
+		jiraLabel, ok := labelMapping[label]
+		if !ok || contains(issue.Fields.Labels, jiraLabel) || contains(missing, jiraLabel) {
+			continue
+		}
+		missing = append(missing, jiraLabel)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if *dryRun {
+		planChange(issue.Key, "labels: [%s] → [%s]", strings.Join(issue.Fields.Labels, ", "), strings.Join(append(append([]string{}, issue.Fields.Labels...), missing...), ", "))
+		return nil
+	}
+
+	klog.V(1).Infof("Adding the labels %s to the issue %s...", strings.Join(missing, ", "), issue.Key)
+
+	var ops []map[string]string
+	for _, label := range missing {
+		ops = append(ops, map[string]string{"add": label})
+	}
+
+	resp, err := jiraClient.Issue.UpdateIssue(issue.Key, map[string]interface{}{
+		"update": map[string]interface{}{
+			"labels": ops,
+		},
+	})
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if isPermissionError(resp) {
+		reportPermissionError(issue.Key, "add labels", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	issue.Fields.Labels = append(issue.Fields.Labels, missing...)
+	return nil
+}
+
+// syncIssueComponent adds the component for the repository of the pull
+// request to the issue.
+func syncIssueComponent(jiraClient *jira.Client, pr *github.PullRequest, issue *jira.Issue) error {
+	fullName := pr.Base.Repo.GetFullName()
+	if oldName, ok := renamedRepos[fullName]; ok {
+		fullName = oldName
+	}
+	component, ok := componentMapping[fullName]
+	if !ok {
+		return nil
+	}
+	return addIssueComponent(jiraClient, issue, component)
+}
+
+// addIssueComponent adds the component to the issue unless it's already
+// there.
+func addIssueComponent(jiraClient *jira.Client, issue *jira.Issue, component string) error {
+	var components []string
+	for _, c := range issue.Fields.Components {
+		if c.Name == component {
+			return nil
+		}
+		components = append(components, c.Name)
+	}
+
+	if *dryRun {
+		planChange(issue.Key, "components: [%s] → [%s]", strings.Join(components, ", "), strings.Join(append(components, component), ", "))
+		return nil
+	}
+
+	klog.V(1).Infof("Adding the component %s to the issue %s...", component, issue.Key)
+
+	resp, err := jiraClient.Issue.UpdateIssue(issue.Key, map[string]interface{}{
+		"update": map[string]interface{}{
+			"components": []map[string]interface{}{
+				{"add": map[string]string{"name": component}},
+			},
+		},
+	})
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if isPermissionError(resp) {
+		reportPermissionError(issue.Key, "add a component", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	issue.Fields.Components = append(issue.Fields.Components, &jira.Component{Name: component})
+	return nil
+}
+
+// checkRequestedReviewers reports the pull request if reviewers were requested,
+// but nobody has reviewed it within -review-nudge-after.
+func checkRequestedReviewers(ctx context.Context, githubClient *github.Client, pr *github.PullRequest) {
+	if len(pr.RequestedReviewers) == 0 {
+		return
+	}
+	age := time.Since(pr.GetCreatedAt())
+	if age < *reviewNudgeAfter {
+		return
+	}
+
+	reviews, _, err := githubClient.PullRequests.ListReviews(ctx, pr.Base.Repo.GetOwner().GetLogin(), pr.Base.Repo.GetName(), pr.GetNumber(), &github.ListOptions{PerPage: 100})
+	if err != nil {
+		fatal(err)
+	}
+	for _, review := range reviews {
+		if review.GetUser().GetLogin() != pr.User.GetLogin() {
+			return
+		}
+	}
+
+	var reviewers []string
+	for _, user := range pr.RequestedReviewers {
+		reviewers = append(reviewers, user.GetLogin())
+	}
+	ruleInfof(1, ruleReviewersNotResponding, "The requested reviewers %s haven't reviewed the pull request %s (opened %s ago): %s", strings.Join(reviewers, ", "), pullRequestLink(pr), age.Round(time.Hour), pr.GetTitle())
+}
+
+func printPullRequestState(ctx context.Context, githubClient *github.Client, pr *github.PullRequest, hasJiraStory bool, hasBZ bool) {
+	if isWorkInProgress(pr) {
+		return
+	}
+
+	if !hasJiraStory && !hasBZ && !contains(pullRequestLabels(pr), *noJiraLabel) {
+		ruleInfof(1, ruleMissingKey, "The pull request %s is not assigned to a bug nor a story: %s", pullRequestLink(pr), pr.GetTitle())
+	}
+
+	if checkRequiredLabels {
+		prLabels := pullRequestLabels(pr)
+		for _, prefix := range requiredLabelPrefixes {
+			if !hasLabelWithPrefix(prLabels, prefix) {
+				ruleInfof(1, ruleMissingLabel, "The pull request %s doesn't have a %s* label: %s", pullRequestLink(pr), prefix, pr.GetTitle())
+			}
+		}
+	}
+
+	labels := map[string]bool{}
+	for _, label := range pr.Labels {
+		labels[label.GetName()] = true
+	}
+
+	if labels["approved"] && labels["lgtm"] {
+		if labels[*holdLabel] {
+			ruleInfof(1, ruleUnhold, "ACTION REQUIRED: Unhold: %s: %s", pullRequestLink(pr), pr.GetTitle())
+			return
+		}
+		if pr.Base.GetRef() != "master" && !labels["cherry-pick-approved"] {
+			ruleInfof(1, ruleAwaitingCherryPick, "Awaiting cherry-pick-approved: %s: %s", pullRequestLink(pr), pr.GetTitle())
+			return
+		}
+		ruleInfof(1, ruleApproved, "Approved: %s: %s", pullRequestLink(pr), pr.GetTitle())
+		return
+	}
+
+	if age := time.Since(pr.GetCreatedAt()); age < *minReviewAge {
+		klog.V(2).Infof("The pull request %s is too new to be reviewed (opened %s ago): %s", pullRequestLink(pr), age.Round(time.Minute), pr.GetTitle())
+		return
+	}
+
+	if *reviewNudgeAfter != 0 {
+		checkRequestedReviewers(ctx, githubClient, pr)
+	}
+
+	ci := ""
+	if *reviewCIStatus {
+		ci = pullRequestCIStatus(ctx, githubClient, pr)
+	}
+
+	var assignees []string
+	for _, user := range pr.Assignees {
+		assignees = append(assignees, user.GetLogin())
+	}
+	kind := ""
+	if hasBZ {
+		kind = "bugfix"
+	} else if hasJiraStory {
+		kind = "feature"
+	}
+
+	var buf bytes.Buffer
+	err := awaitingReviewTemplate.Execute(&buf, awaitingReview{
+		URL:       pullRequestLink(pr),
+		Title:     pr.GetTitle(),
+		Author:    pr.User.GetLogin(),
+		Assignees: assignees,
+		Labels:    pullRequestLabels(pr),
+		Kind:      kind,
+		Age:       time.Since(pr.GetCreatedAt()).Round(time.Minute),
+		CI:        ci,
+	})
+	if err != nil {
+		fatal(err)
+	}
+	ruleInfof(1, ruleAwaitingReview, "%s", buf.String())
+	awaitingReviewLines = append(awaitingReviewLines, buf.String())
+	recordAwaitingReview(pr.User.GetLogin(), time.Since(pr.GetCreatedAt()))
+}
+
+// pullRequestCIStatus returns the combined status of the head commit of the
+// pull request: passing, failing or pending.
+func pullRequestCIStatus(ctx context.Context, githubClient *github.Client, pr *github.PullRequest) string {
+	status, _, err := githubClient.Repositories.GetCombinedStatus(ctx, pr.Base.Repo.GetOwner().GetLogin(), pr.Base.Repo.GetName(), pr.GetHead().GetSHA(), nil)
+	if err != nil {
+		fatal(err)
+	}
+
+	switch status.GetState() {
+	case "success":
+		return "passing"
+	case "failure", "error":
+		return "failing"
+	default:
+		return "pending"
+	}
+}
+
+// awaitingReview is the data for the awaiting review template.
+type awaitingReview struct {
+	URL       string
+	Title     string
+	Author    string
+	Assignees []string // empty if nobody is assigned
+	Labels    []string
+	Kind      string // "feature", "bugfix" or empty
+	Age       time.Duration
+	CI        string // "passing", "failing", "pending" or empty if -review-ci-status is not set
+}
+
+var awaitingReviewTemplate *template.Template
+
+// awaitingReviewLines collects the lines about pull requests that are awaiting
+// review for -tracking-issue.
+var awaitingReviewLines []string
+
+var informationalBranchesRegexp *regexp.Regexp
+
+var wipRegexp *regexp.Regexp
+
+var excludedTitleRegexp *regexp.Regexp
+
+var titleSeparatorRegexp *regexp.Regexp
+
+var branchKeyRegexp *regexp.Regexp
+
+var commentKeyRegexp *regexp.Regexp
+
+// buildWIPRegexp returns a regular expression that matches any of the
+// patterns when they are not a part of a bigger word.
+func buildWIPRegexp(patterns []string) (*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	return regexp.Compile(`(?:^|[^[:alnum:]])(?:` + strings.Join(patterns, `|`) + `)(?:$|[^[:alnum:]])`)
+}
+
+// isExcluded returns true if the pull request should be skipped because its
+// title matches excludedTitlePatterns or it doesn't have -milestone.
+func isExcluded(pr *github.PullRequest) bool {
+	if *milestone != "" && pr.GetMilestone().GetTitle() != *milestone {
+		klog.V(4).Infof("Skipping the pull request %s as it's not in the milestone %s", pullRequestLinkTitle(pr), *milestone)
+		return true
+	}
+	if excludedTitleRegexp != nil && excludedTitleRegexp.MatchString(pr.GetTitle()) {
+		klog.V(3).Infof("Skipping the pull request %s as its title is excluded", pullRequestLinkTitle(pr))
+		return true
+	}
+	return false
+}
+
+// isWorkInProgress returns true if the pull request is a draft or its title
+// has a work in progress marker.
+func isWorkInProgress(pr *github.PullRequest) bool {
+	return pr.GetDraft() || (wipRegexp != nil && wipRegexp.MatchString(pr.GetTitle()))
+}
+
+// pullRequestState is the state of a pull request as far as the status of
+// its issues is concerned.
+type pullRequestState int
+
+const (
+	pullRequestOpen pullRequestState = iota
+	pullRequestDraft
+	pullRequestMerged
+	pullRequestClosedUnmerged
+)
+
+func (s pullRequestState) String() string {
+	switch s {
+	case pullRequestOpen:
+		return "open"
+	case pullRequestDraft:
+		return "draft"
+	case pullRequestMerged:
+		return "merged"
+	case pullRequestClosedUnmerged:
+		return "closed unmerged"
+	}
+	return fmt.Sprintf("pullRequestState(%d)", int(s))
+}
+
+// isMerged returns true if the pull request is merged. The merged flag is not
+// included in pull request lists and may lag behind for pull requests that
+// are merged by a merge queue, so the merge time is checked as well. The
+// merge commit SHA alone is not enough as GitHub sets it for test merges of
+// open and closed pull requests too.
+func isMerged(pr *github.PullRequest) bool {
+	return pr.GetMerged() || !pr.GetMergedAt().IsZero()
+}
+
+// classifyPullRequest returns the state of the pull request. GitHub only has
+// open and closed pull requests, the other states are derived from the
+// draft flag and isMerged.
+func classifyPullRequest(pr *github.PullRequest) pullRequestState {
+	switch {
+	case pr.GetState() == "open" && pr.GetDraft():
+		return pullRequestDraft
+	case pr.GetState() == "open":
+		return pullRequestOpen
+	case isMerged(pr):
+		return pullRequestMerged
+	default:
+		return pullRequestClosedUnmerged
+	}
+}
+
+// resolveGitHubTeams adds the members of githubTeams to team.
+func resolveGitHubTeams(ctx context.Context, githubClient *github.Client) {
+	for _, githubTeam := range githubTeams {
+		parts := strings.SplitN(githubTeam, "/", 2)
+		if len(parts) != 2 {
+			klog.Exitf("Invalid GitHub team %q, want org/slug", githubTeam)
+		}
+
+		klog.V(2).Infof("Resolving members of the GitHub team %s...", githubTeam)
+		opts := &github.TeamListTeamMembersOptions{
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+			},
+		}
+		for {
+			users, resp, err := githubClient.Teams.ListTeamMembersBySlug(ctx, parts[0], parts[1], opts)
+			if err != nil {
+				fatal(err)
+			}
+			for _, user := range users {
+				team[user.GetLogin()] = true
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+}
+
+func assignedToTeam(pr *github.PullRequest) bool {
+	for _, user := range pr.Assignees {
+		if team[user.GetLogin()] {
+			return true
+		}
+	}
+	return false
+}
+
+// listPullRequests returns the recently updated pull requests in the state
+// from the repository, fetching up to -max-pages pages. The pages with pull
+// requests that were updated before updatedSince are not fetched.
+func listPullRequests(ctx context.Context, githubClient *github.Client, repo OwnerName, state string, updatedSince time.Time) []*github.PullRequest {
+	opts := &github.PullRequestListOptions{
+		State:     state,
+		Sort:      "updated",
+		Direction: "desc",
+		ListOptions: github.ListOptions{
+			Page:    1,
+			PerPage: *perPage,
+		},
+	}
+
+	var result []*github.PullRequest
+	for page := 1; *maxPages == 0 || page <= *maxPages; page++ {
+		prs, resp, err := githubClient.PullRequests.List(ctx, repo.Owner, repo.Name, opts)
+		if err != nil {
+			fatal(err)
+		}
+		result = append(result, prs...)
+		checkRenamedRepository(repo, prs)
+
+		if resp.NextPage == 0 || len(prs) == 0 {
+			break
+		}
+		if prs[len(prs)-1].GetUpdatedAt().Before(updatedSince) {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return result
+}
+
+// scanPullRequests returns the pull requests from the repository that should
+// be processed. The reminders need all open pull requests, while linking only
+// needs the ones that were updated since updatedSince, so open and closed pull
+// requests are listed separately. Otherwise old open pull requests could be
+// pushed out of the fetched pages by recently closed ones.
+func scanPullRequests(ctx context.Context, githubClient *github.Client, repo OwnerName, updatedSince time.Time) []*github.PullRequest {
+	var openSince time.Time
+	if !*remindersMode {
+		openSince = updatedSince
+	}
+	prs := listPullRequests(ctx, githubClient, repo, "open", openSince)
+	if *linkMode {
+		prs = append(prs, listPullRequests(ctx, githubClient, repo, "closed", updatedSince)...)
+	}
+	return prs
+}
+
+// titleSeparatorPattern returns the regular expression that matches any of
+// titleSeparators.
+func titleSeparatorPattern() string {
+	return `(?:` + strings.Join(titleSeparators, `|`) + `)`
+}
+
+// buildKeyRegexp returns a regular expression that matches titles that
+// reference issues from the projects, e.g. "IR-1: " or "IR-1/OCPBUGS-2: ".
+// The first submatch is the slash-separated list of the issue keys. The keys
+// should start at a word boundary, so that e.g. "FOOIR-1: " doesn't match the
+// IR project.
+func buildKeyRegexp(projects []string) (*regexp.Regexp, error) {
+	keyPattern := `(?:`
+	for i, projectKey := range projects {
+		if i != 0 {
+			keyPattern += `|`
+		}
+		keyPattern += regexp.QuoteMeta(projectKey)
+	}
+	keyPattern += `)-[0-9]+`
+	return regexp.Compile(`\b(` + keyPattern + `(?:/` + keyPattern + `)*)` + titleSeparatorPattern())
+}
+
+// issueProject returns the project key of the issue, e.g. IR for IR-123.
+func issueProject(issueKey string) string {
+	i := strings.LastIndex(issueKey, "-")
+	if i == -1 {
+		return ""
+	}
+	return issueKey[:i]
+}
+
+// titleIssueKeys returns the keys of the issues that are referenced by the
+// title.
+func titleIssueKeys(title string, keyRegexp *regexp.Regexp) []string {
+	if *keyPosition == "anywhere" {
+		var issueKeys []string
+		for _, issueKey := range keyRegexp.FindAllString(title, -1) {
+			if !contains(issueKeys, issueKey) {
+				issueKeys = append(issueKeys, issueKey)
+			}
+		}
+		return issueKeys
+	}
+
+	if match := keyRegexp.FindStringSubmatch(title); match != nil {
+		return strings.Split(match[1], "/")
+	}
+	return nil
+}
+
+// localIssueKeys returns the keys of the issues that are referenced by the
+// title or the head branch name of the pull request. Unlike
+// pullRequestIssueKeys, it doesn't need any requests to GitHub.
+func localIssueKeys(pr *github.PullRequest, keyRegexp *regexp.Regexp) []string {
+	if issueKeys := titleIssueKeys(pr.GetTitle(), keyRegexp); len(issueKeys) > 0 {
+		return issueKeys
+	}
+	if issueKeys := defaultProjectIssueKeys(pr); len(issueKeys) > 0 {
+		return issueKeys
+	}
+	return branchIssueKeys(pr)
+}
+
+// pullRequestIssueKeys returns the keys of the issues that are referenced by
+// the pull request title. If the title doesn't reference any issues, the keys
+// are extracted from the head branch name using -branch-key-pattern and then,
+// if -keys-from-comments is set, from the comments on the pull request.
+func pullRequestIssueKeys(ctx context.Context, githubClient *github.Client, pr *github.PullRequest, keyRegexp *regexp.Regexp) []string {
+	if issueKeys := localIssueKeys(pr, keyRegexp); len(issueKeys) > 0 {
+		return issueKeys
+	}
+	if *keysFromComments {
+		return commentIssueKeys(ctx, githubClient, pr)
+	}
+	return nil
+}
+
+// bareKeyRegexp matches titles that reference an issue only by its number,
+// e.g. "#123: " or "123: ". The first submatch is the reference, the second
+// one is the number.
+var bareKeyRegexp *regexp.Regexp
+
+// defaultProjectIssueKeys expands a bare reference in the pull request title
+// using the default project of the repository. Repositories without a default
+// project are not affected as such references are ambiguous.
+func defaultProjectIssueKeys(pr *github.PullRequest) []string {
+	repo := repositoryConfig(pr.Base.Repo.GetFullName())
+	if repo == nil || repo.DefaultProject == "" {
+		return nil
+	}
+
+	match := bareKeyRegexp.FindStringSubmatch(pr.GetTitle())
+	if match == nil {
+		return nil
+	}
+
+	issueKey := repo.DefaultProject + "-" + match[2]
+	klog.Infof("%s: expanded the bare reference %q to %s using the default project of %s", pullRequestLinkTitle(pr), match[1], issueKey, pr.Base.Repo.GetFullName())
+	return []string{issueKey}
+}
+
+func branchIssueKeys(pr *github.PullRequest) []string {
+	if branchKeyRegexp == nil {
+		return nil
+	}
+
+	branch := pullRequestHeadRef(pr)
+	if branch == "" {
+		return nil
+	}
+	match := branchKeyRegexp.FindStringSubmatch(branch)
+	if match == nil {
+		return nil
+	}
+
+	issueKey := match[1]
+	if !contains(jiraProjects, issueProject(issueKey)) {
+		klog.V(2).Infof("%s: the branch %s references %s, which is not from a known project", pullRequestLinkTitle(pr), branch, issueKey)
+		return nil
+	}
+
+	klog.V(2).Infof("%s: using the issue %s from the branch %s", pullRequestLinkTitle(pr), issueKey, branch)
+	return []string{issueKey}
+}
+
+// commentIssueKeys returns the keys of the issues that are mentioned in the
+// comments on the pull request, e.g. when a reviewer points to the issue that
+// the author forgot to reference.
+func commentIssueKeys(ctx context.Context, githubClient *github.Client, pr *github.PullRequest) []string {
+	var issueKeys []string
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		comments, resp, err := githubClient.Issues.ListComments(ctx, pr.Base.Repo.GetOwner().GetLogin(), pr.Base.Repo.GetName(), pr.GetNumber(), opts)
+		if err != nil {
+			fatal(err)
+		}
+		for _, comment := range comments {
+			if strings.Contains(comment.GetBody(), commentMarker) {
+				continue
+			}
+			for _, issueKey := range commentKeyRegexp.FindAllString(comment.GetBody(), -1) {
+				if !contains(issueKeys, issueKey) {
+					klog.V(2).Infof("%s: using the issue %s from the comment by %s", pullRequestLinkTitle(pr), issueKey, comment.GetUser().GetLogin())
+					issueKeys = append(issueKeys, issueKey)
+				}
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return issueKeys
+}
+
+// buildKeyAnywhereRegexp returns a regular expression that matches issue keys
+// from the projects anywhere in titles.
+func buildKeyAnywhereRegexp(projects []string) (*regexp.Regexp, error) {
+	var quoted []string
+	for _, projectKey := range projects {
+		quoted = append(quoted, regexp.QuoteMeta(projectKey))
+	}
+	return regexp.Compile(`\b(?:` + strings.Join(quoted, `|`) + `)-[0-9]+\b`)
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if *printVersion {
+		fmt.Printf("github-jira-integration %s (commit %s, built %s)\n", version, commit, buildDate)
+		return
+	}
+	runID = *runIDValue
+	if runID == "" {
+		var err error
+		runID, err = newRunID()
+		if err != nil {
+			fatal(err)
+		}
+	}
+	klog.V(2).InfoS("Starting github-jira-integration", "version", version, "commit", commit, "buildDate", buildDate, "runID", runID)
+
+	baseTransport, err := newBaseTransport(*caFile)
+	if err != nil {
+		klog.Exitf("Unable to set up the HTTP transport: %v", err)
+	}
+
+	if *configPath != "" {
+		if err := loadConfig(*configPath, *configProfile, baseTransport); err != nil {
+			klog.Exitf("Unable to load the configuration: %v", err)
+		}
+	} else if *configProfile != "" {
+		klog.Exit("The -profile flag requires -config")
+	}
+
+	if value := os.Getenv("JIRA_HEADERS"); value != "" {
+		headers, err := parseHeaders(value)
+		if err != nil {
+			klog.Exitf("Unable to parse JIRA_HEADERS: %v", err)
+		}
+		for name, value := range headers {
+			jiraHeaders[name] = value
+		}
+	}
+
+	if *printConfig {
+		cfg, err := printableConfig()
+		if err != nil {
+			fatal(err)
+		}
+		data, err := yaml.Marshal(cfg)
+		if err != nil {
+			fatal(err)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+
+	logRunID()
+
+	awaitingReviewTemplate, err = template.New("awaiting-review").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(*awaitingReviewFormat)
+	if err != nil {
+		klog.Exitf("Unable to parse -awaiting-review-template: %v", err)
+	}
+
+	if *commentMarkerName == "" || strings.Contains(*commentMarkerName, "--") || strings.Contains(*commentMarkerName, ">") {
+		klog.Exitf("Invalid -comment-marker value %q, it should be non-empty and can't contain -- or >", *commentMarkerName)
+	}
+	commentMarker = "<!-- " + *commentMarkerName + " -->"
+	trackingStartMarker = "<!-- " + *commentMarkerName + ":awaiting-review:start -->"
+	trackingEndMarker = "<!-- " + *commentMarkerName + ":awaiting-review:end -->"
+
+	if *trackingIssue != "" {
+		trackingIssueRef.Owner, trackingIssueRef.Repo, trackingIssueRef.Number, err = parseIssueRef(*trackingIssue)
+		if err != nil {
+			klog.Exitf("Invalid -tracking-issue: %v", err)
+		}
+	}
+
+	if *perPage < 1 || *perPage > 100 {
+		klog.Exitf("Invalid -per-page value %d, want a number between 1 and 100", *perPage)
+	}
+	if *maxPages < 0 {
+		klog.Exitf("Invalid -max-pages value %d, want a non-negative number", *maxPages)
+	}
+
+	for state := range linkIcons {
+		if state != "open" && state != "draft" && state != "merged" && state != "closed" {
+			klog.Exitf("Invalid state %q in linkIcons, want open, draft, merged or closed", state)
+		}
+	}
+
+	if pullRequestURLFieldFormat != "single" && pullRequestURLFieldFormat != "list" {
+		klog.Exitf("Invalid pullRequestURLFieldFormat %q, want single or list", pullRequestURLFieldFormat)
+	}
+
+	if *holdWithDoneIssue != "warn" && *holdWithDoneIssue != "ignore" {
+		klog.Exitf("Invalid -hold-with-done-issue value %q, want warn or ignore", *holdWithDoneIssue)
+	}
+
+	if *subtasks != "check" && *subtasks != "skip" && *subtasks != "parent" {
+		klog.Exitf("Invalid -subtasks value %q, want check, skip or parent", *subtasks)
+	}
+
+	wipRegexp, err = buildWIPRegexp(wipPatterns)
+	if err != nil {
+		klog.Exitf("Unable to parse WIP patterns: %v", err)
+	}
+
+	if len(excludedTitlePatterns) > 0 {
+		excludedTitleRegexp, err = regexp.Compile(`(?:` + strings.Join(excludedTitlePatterns, `)|(?:`) + `)`)
+		if err != nil {
+			klog.Exitf("Unable to parse excluded title patterns: %v", err)
+		}
+	}
+
+	if *branchKeyPattern != "" {
+		branchKeyRegexp, err = regexp.Compile(*branchKeyPattern)
+		if err != nil {
+			klog.Exitf("Unable to parse -branch-key-pattern: %v", err)
+		}
+		if branchKeyRegexp.NumSubexp() < 1 {
+			klog.Exitf("The -branch-key-pattern %q should have a group for the issue key", *branchKeyPattern)
+		}
+	}
+
+	if *informationalBranches != "" {
+		informationalBranchesRegexp, err = regexp.Compile(*informationalBranches)
+		if err != nil {
+			klog.Exitf("Unable to parse -informational-branches: %v", err)
+		}
+	}
+
+	if len(titleSeparators) == 0 {
+		klog.Exit("At least one title separator is required")
+	}
+	titleSeparatorRegexp, err = regexp.Compile(titleSeparatorPattern())
+	if err != nil {
+		klog.Exitf("Unable to parse title separators: %v", err)
+	}
+	bareKeyRegexp = regexp.MustCompile(`^(#?([0-9]+))` + titleSeparatorPattern())
+
+	var keyRegexp *regexp.Regexp
+	switch *keyPosition {
+	case "prefix":
+		keyRegexp, err = buildKeyRegexp(jiraProjects)
+	case "anywhere":
+		keyRegexp, err = buildKeyAnywhereRegexp(jiraProjects)
+	default:
+		klog.Exitf("Invalid -key-position value %q, want prefix or anywhere", *keyPosition)
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	if len(bugPatterns) == 0 {
+		klog.Exit("At least one bug pattern is required")
+	}
+	bugRegexp, err := regexp.Compile(`(?:` + strings.Join(bugPatterns, `|`) + `)` + titleSeparatorPattern())
+	if err != nil {
+		klog.Exitf("Unable to parse bug patterns: %v", err)
+	}
+
+	if *keysFromComments {
+		commentKeyRegexp, err = buildKeyAnywhereRegexp(jiraProjects)
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	if *checkTitleValue != "" {
+		if !checkTitle(*checkTitleValue, keyRegexp, bugRegexp) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	baseURL := getEnv("JIRA_BASE_URL")
+	if err := checkTLS("JIRA_BASE_URL", baseURL); err != nil {
+		klog.Exit(err)
+	}
+	tp := jira.BasicAuthTransport{
+		Username: getEnv("JIRA_USERNAME"),
+		Password: getEnv("JIRA_PASSWORD"),
+	}
+
+	headers := map[string]string{
+		"User-Agent":        *userAgent,
+		correlationIDHeader: runID,
+	}
+	for name, value := range jiraHeaders {
+		headers[name] = value
+	}
+	tp.Transport = &headerTransport{Headers: headers, Transport: baseTransport}
+
+	ctx := context.Background()
+
+	if *reportPath != "" {
+		sink, err := newReportSink(*reportPath, *reportFormat)
+		if err != nil {
+			fatal(err)
+		}
+		resultSinks = append(resultSinks, sink)
+	}
+	if *deltasOnly {
+		sink, err := newDeltaSink(*stateFile, os.Stdout)
+		if err != nil {
+			klog.Exit(err)
+		}
+		resultSinks = append(resultSinks, sink)
+	}
+	if *junitPath != "" {
+		sink, err := newJUnitSink(*junitPath, *junitGranularity)
+		if err != nil {
+			fatal(err)
+		}
+		resultSinks = append(resultSinks, sink)
+	}
+	if *resultWebhook != "" {
+		resultSinks = append(resultSinks, newWebhookSink(*resultWebhook, *resultWebhookType, *resultWebhookRetries, baseTransport))
+	}
+	for _, newSink := range optionalResultSinks {
+		sink, err := newSink()
+		if err != nil {
+			fatal(err)
+		}
+		if sink != nil {
+			resultSinks = append(resultSinks, sink)
+		}
+	}
+	if *sortResults && len(resultSinks) > 0 {
+		resultSinks = []resultSink{newSortedSink(resultSinks)}
+	}
+	defer closeResultSinks()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		klog.Warningf("Received %s, saving partial results...", sig)
+		flushFindings()
+		abortResultSinks(fmt.Sprintf("interrupted by %s", sig))
+		klog.Flush()
+		os.Exit(1)
+	}()
+
+	jiraHTTPClient := tp.Client()
+	jiraHTTPClient.Timeout = *httpTimeout
+	jiraClient, err := jira.NewClient(jiraHTTPClient, baseURL)
+	if err != nil {
+		fatal(err)
+	}
+
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	if *commentOnMismatch || *projectStatus || *trackingIssue != "" || len(githubTeams) > 0 {
+		githubToken = getEnv("GITHUB_TOKEN")
+	}
+
+	githubTransport := &headerTransport{
+		Headers:   map[string]string{correlationIDHeader: runID},
+		Transport: baseTransport,
+	}
+	githubHTTPClient := &http.Client{Transport: githubTransport}
+	if githubToken != "" {
+		githubHTTPClient = (&githubTokenTransport{Token: githubToken, Transport: githubTransport}).Client()
+	}
+	githubHTTPClient.Timeout = *httpTimeout
+
+	githubClient := github.NewClient(githubHTTPClient)
+	githubClient.UserAgent = *userAgent
+	if err := checkTLS("the GitHub API URL", githubClient.BaseURL.String()); err != nil {
+		klog.Exit(err)
+	}
+
+	resolveGitHubTeams(ctx, githubClient)
+
+	if *bootstrapMode {
+		bootstrap(ctx, jiraClient, githubClient, keyRegexp)
+		return
+	}
+
+	if *auditMode {
+		auditRemoteLinks(ctx, jiraClient, githubClient)
+		return
+	}
+
+	if *importCSVPath != "" {
+		importCSV(ctx, jiraClient, githubClient, *importCSVPath)
+		return
+	}
+
+	if *linkCommitsMode {
+		linkCommits(ctx, jiraClient, githubClient)
+		return
+	}
+
+	if *reconcileIssueKey != "" {
+		if !issueKeyRegexp.MatchString(*reconcileIssueKey) {
+			klog.Exitf("Invalid -reconcile-issue value %q, want an issue key like IR-123", *reconcileIssueKey)
+		}
+		reconcileIssue(ctx, jiraClient, githubClient, keyRegexp, *reconcileIssueKey)
+		return
+	}
+
+	if *fromStdin {
+		if !processPullRequestRefs(ctx, jiraClient, githubClient, keyRegexp, os.Stdin, os.Stdout) {
+			closeResultSinks()
+			os.Exit(1)
+		}
+		return
+	}
+
+	var updatedSince time.Time
+	if *linkUpdatedSince != 0 {
+		updatedSince = time.Now().Add(-*linkUpdatedSince)
+	}
+
+	// The deadline only stops the processing of new pull requests, so that
+	// the requests that are in flight are not interrupted.
+	deadlineCtx := context.Background()
+	if *maxRuntime != 0 {
+		var cancel context.CancelFunc
+		deadlineCtx, cancel = context.WithTimeout(deadlineCtx, *maxRuntime)
+		defer cancel()
+	}
+
+	processed, skipped := 0, 0
+	var skippedRepos []string
+	for _, repo := range repositories {
+		if deadlineCtx.Err() != nil {
+			skippedRepos = append(skippedRepos, repo.Owner+"/"+repo.Name)
+			continue
+		}
+
+		klog.V(2).Infof("Analyzing github repository %s/%s...", repo.Owner, repo.Name)
+		prs := scanPullRequests(ctx, githubClient, repo, updatedSince)
+
+		// The issue keys are extracted up front, so that the issues can be
+		// fetched in bulk.
+		var pending []pullRequestIssues
+		var linkedKeys []string
+		for _, pr := range prs {
+			if isExcluded(pr) {
+				continue
+			}
+			// The comments are fetched only for the pull requests that
+			// are going to be linked.
+			var issueKeys []string
+			if *linkMode && repo.jiraEnabled() && !pr.GetUpdatedAt().Before(updatedSince) {
+				issueKeys = pullRequestIssueKeys(ctx, githubClient, pr, keyRegexp)
+				linkedKeys = append(linkedKeys, issueKeys...)
+			} else {
+				issueKeys = localIssueKeys(pr, keyRegexp)
+			}
+			pending = append(pending, pullRequestIssues{pr: pr, issueKeys: issueKeys})
+		}
+		if *linkMode && *prefetchIssues {
+			prefetchIssuesByKey(jiraClient, linkedKeys)
+		}
+
+		for i, item := range pending {
+			if deadlineCtx.Err() != nil {
+				skipped += len(pending) - i
+				break
+			}
+			processed++
+
+			pr, issueKeys := item.pr, item.issueKeys
+			setFindingPullRequest(pr)
+
+			if *remindersMode && pr.GetState() == "open" && (team[pr.User.GetLogin()] || assignedToTeam(pr) || teamRepos[pr.Base.Repo.GetFullName()]) {
+				hasJiraStory := len(issueKeys) > 0
+				hasBZ := bugRegexp.MatchString(pr.GetTitle())
+				printPullRequestState(ctx, githubClient, pr, hasJiraStory, hasBZ)
+			}
+
+			if !*linkMode || !repo.jiraEnabled() || len(issueKeys) == 0 {
+				continue
+			}
+			if pr.GetUpdatedAt().Before(updatedSince) {
+				continue
+			}
+			linkPullRequestToIssues(ctx, jiraClient, githubClient, pr, issueKeys)
+		}
+	}
+	flushFindings()
+
+	if *graphPath != "" {
+		if err := writeGraph(*graphPath); err != nil {
+			fatal(err)
+		}
+	}
+
+	if *trackingIssue != "" {
+		updateTrackingIssue(ctx, githubClient, *trackingIssue, awaitingReviewLines)
+	}
+
+	if *reviewBacklogPath != "" {
+		if err := writeReviewBacklog(*reviewBacklogPath, *reviewBacklogMaxAuthors); err != nil {
+			fatal(err)
+		}
+	}
+
+	printPermissionErrors()
+	printPlannedChanges()
+
+	if *reportDuplicates {
+		printDuplicatePullRequests()
+	}
+
+	if len(openPullRequestsWithDoneIssues) > 0 {
+		ruleWarningf(ruleOpenWithDoneIssue, "Found %d open pull requests linked to done issues:", len(openPullRequestsWithDoneIssues))
+		for _, item := range openPullRequestsWithDoneIssues {
+			ruleWarningf(ruleOpenWithDoneIssue, "  %s", item)
+		}
+	}
+
+	if deadlineCtx.Err() != nil {
+		klog.Warningf("The maximum runtime of %s is exceeded: processed %d pull requests, skipped %d pull requests and %d repositories", *maxRuntime, processed, skipped, len(skippedRepos))
+		if len(skippedRepos) > 0 {
+			klog.Warningf("Skipped repositories: %s", strings.Join(skippedRepos, ", "))
 		}
 	}
 }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	testCases := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "canonical",
+			url:  "https://github.com/openshift/api/pull/1",
+			want: "https://github.com/openshift/api/pull/1",
+		},
+		{
+			name: "scheme and host case",
+			url:  "HTTP://GitHub.COM/openshift/api/pull/1",
+			want: "https://github.com/openshift/api/pull/1",
+		},
+		{
+			name: "path case is preserved",
+			url:  "https://github.com/OpenShift/API/pull/1",
+			want: "https://github.com/OpenShift/API/pull/1",
+		},
+		{
+			name: "trailing slash",
+			url:  "https://github.com/openshift/api/pull/1/",
+			want: "https://github.com/openshift/api/pull/1",
+		},
+		{
+			name: "git suffix",
+			url:  "https://github.com/openshift/api.git/pull/1",
+			want: "https://github.com/openshift/api/pull/1",
+		},
+		{
+			name: "git suffix of repository",
+			url:  "https://github.com/openshift/api.git",
+			want: "https://github.com/openshift/api",
+		},
+		{
+			name: "files tab",
+			url:  "https://github.com/openshift/api/pull/1/files",
+			want: "https://github.com/openshift/api/pull/1",
+		},
+		{
+			name: "files tab with trailing slash",
+			url:  "https://github.com/openshift/api/pull/1/files/",
+			want: "https://github.com/openshift/api/pull/1",
+		},
+		{
+			name: "query",
+			url:  "https://github.com/openshift/api/pull/1?w=1",
+			want: "https://github.com/openshift/api/pull/1",
+		},
+		{
+			name: "fragment",
+			url:  "https://github.com/openshift/api/pull/1#issuecomment-123",
+			want: "https://github.com/openshift/api/pull/1",
+		},
+		{
+			name: "invalid URL is kept as is",
+			url:  "https://github.com/%zz",
+			want: "https://github.com/%zz",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := normalizeURL(tc.url); got != tc.want {
+				t.Errorf("normalizeURL(%q) = %q, want %q", tc.url, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildKeyRegexp(t *testing.T) {
+	testCases := []struct {
+		name     string
+		projects []string
+		title    string
+		want     []string
+	}{
+		{
+			name:     "start of title",
+			projects: []string{"IR"},
+			title:    "IR-123: Fix the registry",
+			want:     []string{"IR-123"},
+		},
+		{
+			name:     "several keys",
+			projects: []string{"IR", "OCPBUGS"},
+			title:    "IR-1/OCPBUGS-2: Fix the registry",
+			want:     []string{"IR-1", "OCPBUGS-2"},
+		},
+		{
+			name:     "longer project key",
+			projects: []string{"IR"},
+			title:    "FOOIR-123: Fix the registry",
+		},
+		{
+			name:     "project key with a digit prefix",
+			projects: []string{"IR"},
+			title:    "1IR-123: Fix the registry",
+		},
+		{
+			name:     "after a parenthesis",
+			projects: []string{"IR"},
+			title:    "(IR-123: Fix the registry",
+			want:     []string{"IR-123"},
+		},
+		{
+			name:     "after a slash",
+			projects: []string{"IR"},
+			title:    "[release-4.6] cherry-pick/IR-123: Fix the registry",
+			want:     []string{"IR-123"},
+		},
+		{
+			name:     "no separator",
+			projects: []string{"IR"},
+			title:    "IR-123 Fix the registry",
+		},
+		{
+			name:     "no number",
+			projects: []string{"IR"},
+			title:    "IR-: Fix the registry",
+		},
+		{
+			name:     "unknown project",
+			projects: []string{"IR"},
+			title:    "OCPBUGS-1: Fix the registry",
+		},
+		{
+			name:     "metacharacters in project key",
+			projects: []string{"A.B", "C+"},
+			title:    "A.B-1/C+-2: Fix the registry",
+			want:     []string{"A.B-1", "C+-2"},
+		},
+		{
+			name:     "metacharacters are quoted",
+			projects: []string{"A.B"},
+			title:    "AXB-1: Fix the registry",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyRegexp, err := buildKeyRegexp(tc.projects)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := titleIssueKeys(tc.title, keyRegexp)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("titleIssueKeys(%q) = %q, want %q", tc.title, got, tc.want)
+			}
+		})
+	}
+}
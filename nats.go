@@ -0,0 +1,71 @@
+//go:build nats
+// +build nats
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	natsURL     = flag.String("nats-url", "", "publish the results as JSON messages to the NATS server at this URL")
+	natsSubject = flag.String("nats-subject", "github-jira-integration.results", "the NATS subject for the results")
+)
+
+func init() {
+	optionalResultSinks = append(optionalResultSinks, func() (resultSink, error) {
+		if *natsURL == "" {
+			return nil, nil
+		}
+		return newNATSSink(*natsURL, *natsSubject)
+	})
+}
+
+// natsSink publishes each result as a JSON message as soon as it is produced.
+// It's only available in binaries that are built with the nats tag.
+type natsSink struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func newNATSSink(url string, subject string) (*natsSink, error) {
+	conn, err := nats.Connect(url, nats.Name("github-jira-integration"))
+	if err != nil {
+		return nil, err
+	}
+	return &natsSink{
+		conn:    conn,
+		subject: subject,
+	}, nil
+}
+
+func (s *natsSink) Write(r result) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.conn.Publish(s.subject, data)
+}
+
+func (s *natsSink) Close() error {
+	defer s.conn.Close()
+	return s.conn.Flush()
+}
+
+// Abort publishes a partialResults message without results, as the results
+// are already published.
+func (s *natsSink) Abort(reason string) error {
+	data, err := json.Marshal(partialResults{Partial: true, Reason: reason})
+	if err != nil {
+		s.conn.Close()
+		return err
+	}
+	if err := s.conn.Publish(s.subject, data); err != nil {
+		s.conn.Close()
+		return err
+	}
+	return s.Close()
+}
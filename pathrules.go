@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+)
+
+// pathRule associates the pull requests that change files matching Paths with
+// the Jira project and, optionally, the component.
+type pathRule struct {
+	// Paths are glob patterns like "pkg/registry/*.go". A pattern that ends
+	// with "/**" matches everything in the directory.
+	Paths []string `json:"paths"`
+
+	Project   string `json:"project"`
+	Component string `json:"component,omitempty"`
+}
+
+func (r pathRule) matches(filename string) bool {
+	for _, pattern := range r.Paths {
+		if strings.HasSuffix(pattern, "/**") {
+			if strings.HasPrefix(filename, strings.TrimSuffix(pattern, "**")) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, filename); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pullRequestFiles caches the names of the files that are changed by pull
+// requests.
+var pullRequestFiles = map[string][]string{}
+
+func listPullRequestFiles(ctx context.Context, githubClient *github.Client, pr *github.PullRequest) []string {
+	if files, ok := pullRequestFiles[pullRequestLink(pr)]; ok {
+		return files
+	}
+
+	var files []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := githubClient.PullRequests.ListFiles(ctx, pr.Base.Repo.GetOwner().GetLogin(), pr.Base.Repo.GetName(), pr.GetNumber(), opts)
+		if err != nil {
+			fatal(err)
+		}
+		for _, file := range page {
+			files = append(files, file.GetFilename())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	pullRequestFiles[pullRequestLink(pr)] = files
+	return files
+}
+
+// matchedPathRules returns the path rules of the repository that match the
+// files of the pull request.
+func matchedPathRules(ctx context.Context, githubClient *github.Client, pr *github.PullRequest) []pathRule {
+	repo := repositoryConfig(pr.Base.Repo.GetFullName())
+	if repo == nil || len(repo.PathRules) == 0 {
+		return nil
+	}
+
+	var matched []pathRule
+	files := listPullRequestFiles(ctx, githubClient, pr)
+	for _, rule := range repo.PathRules {
+		for _, filename := range files {
+			if rule.matches(filename) {
+				matched = append(matched, rule)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// checkPathRules reports the projects whose files are changed by the pull
+// request, but that are not referenced by it.
+func checkPathRules(ctx context.Context, githubClient *github.Client, pr *github.PullRequest, issueKeys []string) {
+	for _, rule := range matchedPathRules(ctx, githubClient, pr) {
+		referenced := false
+		for _, issueKey := range issueKeys {
+			if issueProject(issueKey) == rule.Project {
+				referenced = true
+				break
+			}
+		}
+		if !referenced {
+			ruleInfof(1, ruleMissingPathProject, "The pull request %s changes files of the project %s, but doesn't reference its issues: %s", pullRequestLink(pr), rule.Project, pr.GetTitle())
+		}
+	}
+}
+
+// syncPathRuleComponents adds the components of the matched path rules for
+// the project of the issue.
+func syncPathRuleComponents(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, pr *github.PullRequest, issue *jira.Issue) error {
+	for _, rule := range matchedPathRules(ctx, githubClient, pr) {
+		if rule.Component != "" && rule.Project == issueProject(issue.Key) {
+			if err := addIssueComponent(jiraClient, issue, rule.Component); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
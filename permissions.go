@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andygrunwald/go-jira"
+	"k8s.io/klog/v2"
+)
+
+// permissionErrors collects the Jira operations that failed because the user
+// lacks permissions, so that they can be summarized at the end of the run.
+var permissionErrors []string
+
+// isPermissionError returns true if Jira rejected the request because the
+// user is not authenticated or lacks permissions.
+func isPermissionError(resp *jira.Response) bool {
+	return resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden)
+}
+
+func reportPermissionError(issueKey string, action string, err error) {
+	ruleWarningf(rulePermission, "Insufficient permissions for %s: unable to %s: %v", issueKey, action, err)
+	permissionErrors = append(permissionErrors, fmt.Sprintf("%s: unable to %s", issueKey, action))
+}
+
+func printPermissionErrors() {
+	if len(permissionErrors) == 0 {
+		return
+	}
+	klog.Warningf("Got %d permission errors from Jira, please check the roles of the Jira user:", len(permissionErrors))
+	for _, item := range permissionErrors {
+		klog.Warningf("  %s", item)
+	}
+}
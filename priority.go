@@ -0,0 +1,95 @@
+package main
+
+import (
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// priorityMapping maps GitHub pull request labels to Jira priorities that
+// should be set on the linked issue, e.g. {"priority/critical": "Urgent"}.
+var priorityMapping = map[string]string{}
+
+// priorityRanks maps the names of Jira priorities to their ranks, 0 is the
+// highest priority. It's loaded on first use.
+var priorityRanks map[string]int
+
+func loadPriorityRanks(jiraClient *jira.Client) {
+	if priorityRanks != nil {
+		return
+	}
+
+	priorities, resp, err := jiraClient.Priority.GetList()
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	priorityRanks = map[string]int{}
+	for i, priority := range priorities {
+		priorityRanks[priority.Name] = i
+	}
+}
+
+// syncIssuePriority raises the priority of the issue according to the labels
+// of the pull request. Priorities are never lowered.
+func syncIssuePriority(jiraClient *jira.Client, pr *github.PullRequest, issue *jira.Issue) error {
+	if len(priorityMapping) == 0 {
+		return nil
+	}
+
+	loadPriorityRanks(jiraClient)
+
+	want := ""
+	for _, label := range pullRequestLabels(pr) {
+		priority, ok := priorityMapping[label]
+		if !ok {
+			continue
+		}
+		if _, ok := priorityRanks[priority]; !ok {
+			klog.Warningf("The priority %q for the label %s doesn't exist in Jira", priority, label)
+			continue
+		}
+		if want == "" || priorityRanks[priority] < priorityRanks[want] {
+			want = priority
+		}
+	}
+	if want == "" {
+		return nil
+	}
+
+	current := ""
+	if issue.Fields.Priority != nil {
+		current = issue.Fields.Priority.Name
+	}
+	if rank, ok := priorityRanks[current]; ok && rank <= priorityRanks[want] {
+		return nil
+	}
+
+	if *dryRun {
+		planChange(issue.Key, "priority: %s → %s", current, want)
+		return nil
+	}
+
+	klog.V(1).Infof("Setting the priority of the issue %s to %s...", issue.Key, want)
+
+	resp, err := jiraClient.Issue.UpdateIssue(issue.Key, map[string]interface{}{
+		"fields": map[string]interface{}{
+			"priority": map[string]string{"name": want},
+		},
+	})
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if isPermissionError(resp) {
+		reportPermissionError(issue.Key, "set the priority", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	issue.Fields.Priority = &jira.Priority{Name: want}
+	return nil
+}
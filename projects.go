@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// projectStatusMapping maps the values of the Status field on GitHub
+// Projects to the corresponding Jira statuses.
+var projectStatusMapping = map[string][]string{
+	"Todo":        {"To Do", "New"},
+	"In Progress": {"In Progress", "Code Review"},
+	"Done":        {"On QA", "Done"},
+}
+
+const projectItemsQuery = `query($owner: String!, $name: String!, $number: Int!, $field: String!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      projectItems(first: 20) {
+        nodes {
+          project {
+            title
+          }
+          fieldValueByName(name: $field) {
+            ... on ProjectV2ItemFieldSingleSelectValue {
+              name
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type projectItem struct {
+	Project struct {
+		Title string `json:"title"`
+	} `json:"project"`
+	FieldValueByName struct {
+		Name string `json:"name"`
+	} `json:"fieldValueByName"`
+}
+
+type projectItemsResponse struct {
+	Data struct {
+		Repository struct {
+			PullRequest struct {
+				ProjectItems struct {
+					Nodes []projectItem `json:"nodes"`
+				} `json:"projectItems"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// getProjectItems returns the GitHub Projects (v2) items that are associated
+// with the pull request.
+func getProjectItems(ctx context.Context, githubClient *github.Client, pr *github.PullRequest) ([]projectItem, error) {
+	req, err := githubClient.NewRequest("POST", "graphql", map[string]interface{}{
+		"query": projectItemsQuery,
+		"variables": map[string]interface{}{
+			"owner":  pr.Base.Repo.GetOwner().GetLogin(),
+			"name":   pr.Base.Repo.GetName(),
+			"number": pr.GetNumber(),
+			"field":  *projectStatusField,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp projectItemsResponse
+	if _, err := githubClient.Do(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("failed to get project items for %s: %s", pullRequestLinkTitle(pr), resp.Errors[0].Message)
+	}
+
+	return resp.Data.Repository.PullRequest.ProjectItems.Nodes, nil
+}
+
+// checkProjectStatus warns if the status of the pull request on GitHub
+// Projects diverges from the status of the Jira issue.
+func checkProjectStatus(ctx context.Context, githubClient *github.Client, pr *github.PullRequest, issueKey string, status string) {
+	items, err := getProjectItems(ctx, githubClient, pr)
+	if err != nil {
+		fatal(err)
+	}
+
+	for _, item := range items {
+		projectStatus := item.FieldValueByName.Name
+		if projectStatus == "" {
+			continue
+		}
+
+		want, ok := projectStatusMapping[projectStatus]
+		if !ok {
+			klog.V(2).Infof("%s: the status %q on the project %q is not mapped to Jira statuses", pullRequestLinkTitle(pr), projectStatus, item.Project.Title)
+			continue
+		}
+
+		if !contains(want, status) {
+			ruleWarningf(ruleProjectStatusMismatch, "%s: the pull request %s is %s on the project %q, but the issue is %s", issueKey, pullRequestLink(pr), projectStatus, item.Project.Title, status)
+		}
+	}
+}
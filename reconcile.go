@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// maxSearchQueryLength is the limit for the length of GitHub search queries.
+const maxSearchQueryLength = 256
+
+// repositoryQueries returns the GitHub search queries that restrict the base
+// query to the configured repositories. The repositories are split into
+// several queries to keep them short.
+func repositoryQueries(base string) []string {
+	var queries []string
+	query := base
+	for _, repo := range repositories {
+		if !repo.jiraEnabled() {
+			continue
+		}
+		qualifier := " repo:" + repo.Owner + "/" + repo.Name
+		if len(query)+len(qualifier) > maxSearchQueryLength && query != base {
+			queries = append(queries, query)
+			query = base
+		}
+		query += qualifier
+	}
+	return append(queries, query)
+}
+
+// findIssuePullRequests returns the pull requests from the configured
+// repositories that reference the issue, either by GitHub search or by the
+// remote links on the issue.
+func findIssuePullRequests(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, issueKey string) []*github.PullRequest {
+	var prURLs []string
+
+	links, resp, err := jiraClient.Issue.GetRemoteLinks(issueKey)
+	if isPermissionError(resp) {
+		reportPermissionError(issueKey, "get remote links", err)
+	} else if err != nil {
+		fatal(err)
+	} else {
+		for _, link := range *links {
+			if _, _, _, err := parsePullRequestURL(link.Object.URL); err == nil {
+				prURLs = append(prURLs, normalizeURL(link.Object.URL))
+			}
+		}
+	}
+
+	for _, query := range repositoryQueries(fmt.Sprintf("%q type:pr", issueKey)) {
+		opts := &github.SearchOptions{
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+			},
+		}
+		for {
+			klog.V(3).Infof("Searching for pull requests: %s", query)
+			found, resp, err := githubClient.Search.Issues(ctx, query, opts)
+			if err != nil {
+				fatal(err)
+			}
+			for _, issue := range found.Issues {
+				prURL := normalizeURL(issue.GetHTMLURL())
+				if !contains(prURLs, prURL) {
+					prURLs = append(prURLs, prURL)
+				}
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+
+	var prs []*github.PullRequest
+	for _, prURL := range prURLs {
+		owner, repo, number, err := parsePullRequestURL(prURL)
+		if err != nil {
+			continue
+		}
+		pr, _, err := githubClient.PullRequests.Get(ctx, owner, repo, number)
+		if err != nil {
+			klog.Warningf("Unable to get the pull request %s: %v", prURL, err)
+			continue
+		}
+		prs = append(prs, pr)
+	}
+	return prs
+}
+
+// reconcileIssue checks the issue against all pull requests that reference it.
+// The pull requests are linked to the issue as usual and the summary of the
+// issue with all its pull requests is printed at the end.
+func reconcileIssue(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, keyRegexp *regexp.Regexp, issueKey string) {
+	prs := findIssuePullRequests(ctx, jiraClient, githubClient, issueKey)
+	klog.V(1).Infof("Found %d pull requests that may reference %s", len(prs), issueKey)
+
+	for _, pr := range prs {
+		if repositoryConfig(pr.Base.Repo.GetFullName()) == nil {
+			klog.V(2).Infof("Skipping the pull request %s from the repository that is not configured", pullRequestLinkTitle(pr))
+			continue
+		}
+		if !contains(pullRequestIssueKeys(ctx, githubClient, pr, keyRegexp), issueKey) {
+			klog.V(2).Infof("The pull request %s mentions %s, but doesn't reference it", pullRequestLinkTitle(pr), issueKey)
+			continue
+		}
+		if err := linkPullRequestToIssue(ctx, jiraClient, githubClient, pr, issueKey); isFatalLinkError(err) {
+			fatal(err)
+		}
+	}
+
+	agg, ok := aggregatedIssues[issueKey]
+	if !ok {
+		klog.Infof("%s: no pull requests reference the issue", issueKey)
+	} else {
+		klog.Infof("%s is %s and is referenced by %d pull requests:", issueKey, agg.Status, len(agg.PullRequests))
+		for _, pr := range agg.PullRequests {
+			klog.Infof("  %s (%s): %s", pullRequestLink(pr), classifyPullRequest(pr), pr.GetTitle())
+		}
+	}
+
+	printPermissionErrors()
+	printPlannedChanges()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// renamedRepos maps the current names of renamed repositories to their names
+// in the configuration. GitHub redirects requests for the old names, so
+// renames are noticed only by the names in the returned pull requests.
+var renamedRepos = map[string]string{}
+
+// checkRenamedRepository detects that the repository is renamed and makes the
+// configuration for the old name apply to the new one.
+func checkRenamedRepository(repo OwnerName, prs []*github.PullRequest) {
+	if len(prs) == 0 {
+		return
+	}
+
+	oldName := repo.Owner + "/" + repo.Name
+	newName := prs[0].Base.Repo.GetFullName()
+	if newName == "" || strings.EqualFold(oldName, newName) {
+		return
+	}
+	if _, ok := renamedRepos[newName]; ok {
+		return
+	}
+
+	ruleWarningf(ruleRenamedRepository, "The repository %s is renamed to %s, please update the configuration", oldName, newName)
+	renamedRepos[newName] = oldName
+	if writeRepos[oldName] {
+		writeRepos[newName] = true
+	}
+	if teamRepos[oldName] {
+		teamRepos[newName] = true
+	}
+}
+
+// oldPullRequestLink returns the link to the pull request under the old name
+// of its repository or an empty string if the repository is not renamed.
+func oldPullRequestLink(pr *github.PullRequest) string {
+	oldName, ok := renamedRepos[pr.Base.Repo.GetFullName()]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("https://github.com/%s/pull/%d", oldName, pr.GetNumber())
+}
+
+// rewriteRemoteLink updates the URL and the title of the existing remote link.
+func rewriteRemoteLink(jiraClient *jira.Client, issueKey string, link jira.RemoteLink, remoteURL string, remoteTitle string) error {
+	if *dryRun {
+		planChange(issueKey, "remote links: %s → %s", link.Object.URL, remoteURL)
+		return nil
+	}
+
+	klog.V(1).Infof("Rewriting the remote link %s on the issue %s to %s...", link.Object.URL, issueKey, remoteURL)
+
+	link.Object.URL = remoteURL
+	link.Object.Title = remoteTitle
+	resp, err := updateRemoteLink(jiraClient, issueKey, link)
+	if isPermissionError(resp) {
+		reportPermissionError(issueKey, "update a remote link", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"k8s.io/klog/v2"
+)
+
+// statusCategories maps the names of Jira statuses to the keys of their
+// categories. It's loaded on first use.
+var statusCategories map[string]string
+
+func loadStatusCategories(jiraClient *jira.Client) {
+	if statusCategories != nil {
+		return
+	}
+
+	statuses, resp, err := jiraClient.Status.GetAllStatuses()
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	statusCategories = map[string]string{}
+	for _, status := range statuses {
+		statusCategories[status.Name] = status.StatusCategory.Key
+	}
+}
+
+// reopenedAt returns when the issue was moved from a done status to its
+// current status. It needs the changelog of the issue.
+func reopenedAt(jiraClient *jira.Client, issue *jira.Issue) (time.Time, bool) {
+	if issue.Changelog == nil {
+		return time.Time{}, false
+	}
+
+	loadStatusCategories(jiraClient)
+
+	var lastChange time.Time
+	reopened := false
+	for _, history := range issue.Changelog.Histories {
+		created, err := history.CreatedTime()
+		if err != nil {
+			continue
+		}
+		for _, item := range history.Items {
+			if item.Field != "status" || created.Before(lastChange) {
+				continue
+			}
+			lastChange = created
+			reopened = statusCategories[item.FromString] == jira.StatusCategoryComplete && statusCategories[item.ToString] != jira.StatusCategoryComplete
+		}
+	}
+	return lastChange, reopened
+}
+
+// recentlyReopened returns true if the issue was reopened within
+// -reopened-grace-period, so that a new pull request is presumably coming.
+func recentlyReopened(jiraClient *jira.Client, issue *jira.Issue) bool {
+	t, ok := reopenedAt(jiraClient, issue)
+	if !ok || time.Since(t) > *reopenedGracePeriod {
+		return false
+	}
+	klog.V(2).Infof("%s was reopened %s ago, not warning about its merged pull requests", issue.Key, time.Since(t).Round(time.Hour))
+	return true
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// jsonReportSink writes the results as a JSON array once all of them are
+// collected. If the run is interrupted, the results are written as a
+// partialResults object.
+type jsonReportSink struct {
+	f       *os.File
+	results []result
+}
+
+func (s *jsonReportSink) Write(r result) error {
+	s.results = append(s.results, r)
+	return nil
+}
+
+func (s *jsonReportSink) Close() error {
+	results := s.results
+	if results == nil {
+		results = []result{}
+	}
+
+	enc := json.NewEncoder(s.f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
+
+func (s *jsonReportSink) Abort(reason string) error {
+	enc := json.NewEncoder(s.f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(partialResults{Partial: true, Reason: reason, Results: s.results}); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
+
+// ndjsonReportSink writes each result as a separate JSON object as soon as it
+// is produced. If the run is interrupted, the last line is a partialResults
+// object without results.
+type ndjsonReportSink struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+func (s *ndjsonReportSink) Write(r result) error {
+	return s.enc.Encode(r)
+}
+
+func (s *ndjsonReportSink) Close() error {
+	return s.f.Close()
+}
+
+func (s *ndjsonReportSink) Abort(reason string) error {
+	if err := s.enc.Encode(partialResults{Partial: true, Reason: reason}); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
+
+func newReportSink(path string, format string) (resultSink, error) {
+	if format != "json" && format != "ndjson" {
+		return nil, fmt.Errorf("unsupported report format %q", format)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "ndjson" {
+		return &ndjsonReportSink{
+			f:   f,
+			enc: json.NewEncoder(f),
+		}, nil
+	}
+	return &jsonReportSink{f: f}, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// result describes the outcome of comparing the status of a Jira issue with
+// the state of a pull request that is linked to it.
+type result struct {
+	Timestamp      time.Time `json:"timestamp"`
+	RunID          string    `json:"run_id"`
+	Repo           string    `json:"repo"`
+	PullRequestURL string    `json:"pr_url"`
+	IssueKey       string    `json:"issue_key"`
+	JiraStatus     string    `json:"jira_status"`
+	ExpectedStatus string    `json:"expected_status"`
+	Mismatch       bool      `json:"mismatch"`
+}
+
+// partialResults is written instead of the complete results when the run is
+// interrupted.
+type partialResults struct {
+	Partial bool     `json:"partial"`
+	Reason  string   `json:"reason"`
+	Results []result `json:"results,omitempty"`
+}
+
+// resultSink consumes results as they are produced.
+type resultSink interface {
+	Write(r result) error
+	Close() error
+
+	// Abort is called instead of Close when the run is interrupted. The
+	// results that are already collected should be saved and marked as
+	// partial.
+	Abort(reason string) error
+}
+
+var runStarted = time.Now()
+
+// optionalResultSinks are the constructors of the sinks that are available
+// only with build tags. A constructor returns nil if its sink is not enabled.
+var optionalResultSinks []func() (resultSink, error)
+
+var (
+	resultSinksMu sync.Mutex
+	resultSinks   []resultSink
+)
+
+// recordResult writes the result to the sinks. If a sink fails, the results
+// that are collected so far are saved as partial by all sinks.
+func recordResult(r result) {
+	r.Timestamp = runStarted
+	r.RunID = runID
+
+	var err error
+	resultSinksMu.Lock()
+	for _, sink := range resultSinks {
+		if err = sink.Write(r); err != nil {
+			break
+		}
+	}
+	resultSinksMu.Unlock()
+
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// closeResultSinks closes the sinks. The sinks cannot be used after that, so
+// a signal that arrives later doesn't abort them. If a sink fails, the sinks
+// that are not closed yet save the results as partial.
+func closeResultSinks() {
+	var err error
+	resultSinksMu.Lock()
+	sinks := resultSinks
+	resultSinks = nil
+	for i, sink := range sinks {
+		if err = sink.Close(); err != nil {
+			resultSinks = sinks[i+1:]
+			break
+		}
+	}
+	resultSinksMu.Unlock()
+
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// fatal saves the results that are collected so far as partial and exits
+// like klog.Fatal. It should be used instead of klog.Fatal once the sinks are
+// set up, as deferred functions don't run on exit.
+func fatal(args ...interface{}) {
+	flushFindings()
+	abortResultSinks(fmt.Sprint(args...))
+	klog.FatalDepth(1, args...)
+}
+
+// abortResultSinks saves the results that are collected so far as partial.
+// The sinks cannot be used after that.
+func abortResultSinks(reason string) {
+	resultSinksMu.Lock()
+	defer resultSinksMu.Unlock()
+
+	for _, sink := range resultSinks {
+		if err := sink.Abort(reason); err != nil {
+			klog.Errorf("Failed to save partial results: %v", err)
+		}
+	}
+	resultSinks = nil
+}
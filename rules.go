@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"k8s.io/klog/v2"
+)
+
+// Rule IDs identify the checks that produce findings. They are stable, so
+// that the output can be grepped or routed by rule.
+const (
+	ruleMissingKey               = "missing-key"
+	ruleMissingLabel             = "missing-label"
+	ruleNotOnHold                = "not-on-hold"
+	ruleReviewersNotResponding   = "reviewers-not-responding"
+	ruleStatusMismatchInProgress = "status-mismatch.in-progress"
+	ruleStatusMismatchReview     = "status-mismatch.review"
+	ruleStatusMismatchMerged     = "status-mismatch.merged"
+	ruleProjectStatusMismatch    = "status-mismatch.project"
+	ruleStuckInReview            = "stuck-in-review"
+	ruleOpenWithDoneIssue        = "open-with-done-issue"
+	ruleHoldWithDoneIssue        = "hold-with-done-issue"
+	ruleMergedWIP                = "merged-wip"
+	ruleMissingFixVersion        = "missing-fix-version"
+	ruleNotInActiveSprint        = "not-in-active-sprint"
+	ruleDuplicatePullRequests    = "duplicate-pull-requests"
+	ruleNonexistentIssue         = "nonexistent-issue"
+	ruleWrongProject             = "wrong-project"
+	ruleMissingPathProject       = "missing-path-project"
+	ruleOrphanedLink             = "orphaned-link"
+	ruleRenamedRepository        = "renamed-repository"
+	rulePermission               = "permission"
+	ruleUnhold                   = "unhold"
+	ruleAwaitingCherryPick       = "awaiting-cherry-pick"
+	ruleApproved                 = "approved"
+	ruleAwaitingReview           = "awaiting-review"
+)
+
+// ruleMessage formats a finding: the message is prefixed with the rule ID and
+// followed by the run ID as a key-value pair unless logRunID already appends
+// it, so that findings from different runs can be told apart in aggregated
+// logs.
+func ruleMessage(rule string, format string, args ...interface{}) string {
+	if logsCarryRunID {
+		return fmt.Sprintf("[%s] %s", rule, fmt.Sprintf(format, args...))
+	}
+	return fmt.Sprintf("[%s] %s runID=%q", rule, fmt.Sprintf(format, args...), runID)
+}
+
+// deltasOnlyLevel is the verbosity level of the findings with -deltas-only, as
+// the changes are printed by deltaSink instead.
+const deltasOnlyLevel klog.Level = 4
+
+// ruleWarningf logs a warning that is prefixed with the rule ID.
+func ruleWarningf(rule string, format string, args ...interface{}) {
+	if *deltasOnly {
+		if klog.V(deltasOnlyLevel).Enabled() {
+			message := ruleMessage(rule, format, args...)
+			if !holdFinding(false, message) {
+				klog.InfoDepth(1, message)
+			}
+		}
+		return
+	}
+	message := ruleMessage(rule, format, args...)
+	if !holdFinding(true, message) {
+		klog.WarningDepth(1, message)
+	}
+}
+
+// ruleInfof logs a message at the verbosity level that is prefixed with the
+// rule ID.
+func ruleInfof(level klog.Level, rule string, format string, args ...interface{}) {
+	if *deltasOnly && level < deltasOnlyLevel {
+		level = deltasOnlyLevel
+	}
+	if klog.V(level).Enabled() {
+		message := ruleMessage(rule, format, args...)
+		if !holdFinding(false, message) {
+			klog.InfoDepth(1, message)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"k8s.io/klog/v2"
+)
+
+// runID is the correlation ID of the run. It's sent to GitHub and Jira in the
+// correlationIDHeader header and included in the startup log line, the log
+// lines (see logRunID) and the results, so that the run can be traced across
+// the systems.
+var runID string
+
+// logsCarryRunID is true once logRunID has set up klog to append the run ID
+// to every log line.
+var logsCarryRunID bool
+
+const correlationIDHeader = "X-Correlation-ID"
+
+// newRunID returns a random (version 4) UUID.
+func newRunID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// runIDWriter appends the run ID as a key-value pair to the log lines. klog
+// writes every line with a single Write call.
+type runIDWriter struct {
+	w io.Writer
+}
+
+func (w runIDWriter) Write(p []byte) (int, error) {
+	line := bytes.TrimSuffix(p, []byte("\n"))
+	buf := make([]byte, 0, len(p)+len(runID)+10)
+	buf = append(buf, line...)
+	buf = append(buf, " runID="...)
+	buf = strconv.AppendQuote(buf, runID)
+	buf = append(buf, '\n')
+	if _, err := w.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// logRunID makes klog append the run ID to every log line that is written to
+// stderr. klog writes to stderr directly with -logtostderr, so the lines are
+// sent through the INFO output instead, which receives the lines of all
+// severities. The log files (-log_file and -log_dir) are left as they are.
+func logRunID() {
+	if f := flag.Lookup("logtostderr"); f == nil || f.Value.String() != "true" {
+		return
+	}
+	klog.Flush()
+	for _, name := range []string{"WARNING", "ERROR", "FATAL"} {
+		klog.SetOutputBySeverity(name, ioutil.Discard)
+	}
+	klog.SetOutputBySeverity("INFO", runIDWriter{w: os.Stderr})
+	if err := flag.Set("stderrthreshold", "4"); err != nil {
+		klog.Exitf("Unable to set up logging: %v", err)
+	}
+	if err := flag.Set("logtostderr", "false"); err != nil {
+		klog.Exitf("Unable to set up logging: %v", err)
+	}
+	logsCarryRunID = true
+}
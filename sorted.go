@@ -0,0 +1,165 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// sortedSink collects the results and passes them to the underlying sinks
+// sorted by repository, pull request number and issue key, so that the output
+// doesn't depend on the processing order.
+type sortedSink struct {
+	mu      sync.Mutex
+	sinks   []resultSink
+	results []result
+}
+
+func newSortedSink(sinks []resultSink) *sortedSink {
+	return &sortedSink{sinks: sinks}
+}
+
+// pullRequestNumber returns the number of the pull request from its URL or 0
+// if the URL doesn't end with a number.
+func pullRequestNumber(url string) int {
+	n, _ := strconv.Atoi(url[strings.LastIndex(url, "/")+1:])
+	return n
+}
+
+func (s *sortedSink) Write(r result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results = append(s.results, r)
+	return nil
+}
+
+// flush writes the sorted results to the underlying sinks.
+func (s *sortedSink) flush() error {
+	sort.SliceStable(s.results, func(i, j int) bool {
+		a, b := s.results[i], s.results[j]
+		if a.Repo != b.Repo {
+			return a.Repo < b.Repo
+		}
+		if na, nb := pullRequestNumber(a.PullRequestURL), pullRequestNumber(b.PullRequestURL); na != nb {
+			return na < nb
+		}
+		return a.IssueKey < b.IssueKey
+	})
+	for _, r := range s.results {
+		for _, sink := range s.sinks {
+			if err := sink.Write(r); err != nil {
+				return err
+			}
+		}
+	}
+	s.results = nil
+	return nil
+}
+
+func (s *sortedSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sortedSink) Abort(reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+	for _, sink := range s.sinks {
+		if err := sink.Abort(reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finding is a log line from ruleWarningf or ruleInfof that is held back by
+// -sort-results until all pull requests are processed.
+type finding struct {
+	repo    string
+	number  int
+	warning bool
+	message string
+}
+
+var (
+	findingsMu sync.Mutex
+
+	// findingPullRequest is the pull request that is being processed. The
+	// findings are held back only while it's set.
+	findingPullRequest *github.PullRequest
+
+	findings []finding
+)
+
+// setFindingPullRequest sets the pull request that the following findings
+// are about. If -sort-results is not set, it does nothing.
+func setFindingPullRequest(pr *github.PullRequest) {
+	if !*sortResults {
+		return
+	}
+
+	findingsMu.Lock()
+	defer findingsMu.Unlock()
+
+	findingPullRequest = pr
+}
+
+// holdFinding saves the message if it's about a pull request and returns
+// true, otherwise the message should be logged as usual.
+func holdFinding(warning bool, message string) bool {
+	findingsMu.Lock()
+	defer findingsMu.Unlock()
+
+	if findingPullRequest == nil {
+		return false
+	}
+	findings = append(findings, finding{
+		repo:    findingPullRequest.Base.Repo.GetFullName(),
+		number:  findingPullRequest.GetNumber(),
+		warning: warning,
+		message: message,
+	})
+	return true
+}
+
+// flushFindings logs the held back findings sorted by repository and pull
+// request number. The findings about the same pull request keep their order.
+func flushFindings() {
+	findingsMu.Lock()
+	defer findingsMu.Unlock()
+
+	findingPullRequest = nil
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].repo != findings[j].repo {
+			return findings[i].repo < findings[j].repo
+		}
+		return findings[i].number < findings[j].number
+	})
+	for _, f := range findings {
+		if f.warning {
+			klog.Warning(f.message)
+		} else {
+			klog.Info(f.message)
+		}
+	}
+	findings = nil
+}
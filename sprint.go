@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+)
+
+// sprintBoards maps Jira projects to the IDs of their Agile boards, which are
+// used to find the active sprints for -check-sprint.
+var sprintBoards = map[string]int{}
+
+// activeSprints caches the IDs of the active sprints by board ID.
+var activeSprints = map[int]map[int]bool{}
+
+func loadActiveSprints(jiraClient *jira.Client, boardID int) (map[int]bool, error) {
+	if sprints, ok := activeSprints[boardID]; ok {
+		return sprints, nil
+	}
+
+	sprints := map[int]bool{}
+	opts := &jira.GetAllSprintsOptions{State: "active"}
+	for {
+		list, resp, err := jiraClient.Board.GetAllSprintsWithOptions(boardID, opts)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the active sprints of the board %d: %w", boardID, err)
+		}
+		for _, sprint := range list.Values {
+			sprints[sprint.ID] = true
+		}
+		if list.IsLast || len(list.Values) == 0 {
+			break
+		}
+		opts.StartAt += len(list.Values)
+	}
+
+	activeSprints[boardID] = sprints
+	return sprints, nil
+}
+
+// checkActiveSprint warns if the issue of the open pull request isn't in an
+// active sprint of the board of its project.
+func checkActiveSprint(jiraClient *jira.Client, pr *github.PullRequest, issueKey string) error {
+	boardID, ok := sprintBoards[issueProject(issueKey)]
+	if !ok {
+		return nil
+	}
+
+	sprints, err := loadActiveSprints(jiraClient, boardID)
+	if err != nil {
+		return err
+	}
+
+	issue, resp, err := jiraClient.Sprint.GetIssue(issueKey, &jira.GetQueryOptions{Fields: "sprint"})
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get the sprint of the issue %s: %w", issueKey, err)
+	}
+
+	if issue.Fields == nil || issue.Fields.Sprint == nil {
+		ruleWarningf(ruleNotInActiveSprint, "The pull request %s is open, but the issue %s is in the backlog", pullRequestLink(pr), issueKey)
+		return nil
+	}
+	if !sprints[issue.Fields.Sprint.ID] {
+		ruleWarningf(ruleNotInActiveSprint, "The pull request %s is open, but the issue %s is in the sprint %s, which is not active on the board %d", pullRequestLink(pr), issueKey, issue.Fields.Sprint.Name, boardID)
+	}
+	return nil
+}
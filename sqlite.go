@@ -0,0 +1,113 @@
+//go:build sqlite
+// +build sqlite
+
+package main
+
+import (
+	"database/sql"
+	"flag"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var sqlitePath = flag.String("sqlite", "", "store the results in the SQLite database at this path")
+
+func init() {
+	optionalResultSinks = append(optionalResultSinks, func() (resultSink, error) {
+		if *sqlitePath == "" {
+			return nil, nil
+		}
+		return newSQLiteSink(*sqlitePath)
+	})
+}
+
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS results (
+	timestamp DATETIME NOT NULL,
+	run_id TEXT NOT NULL DEFAULT '',
+	repo TEXT NOT NULL,
+	pr_url TEXT NOT NULL,
+	issue_key TEXT NOT NULL,
+	jira_status TEXT NOT NULL,
+	expected_status TEXT NOT NULL,
+	mismatch BOOLEAN NOT NULL
+)`
+
+// sqliteSink stores results in a SQLite database, so that they can be
+// analyzed across runs. The driver needs cgo, so the sink is only available in
+// binaries that are built with the sqlite tag and CGO_ENABLED=1.
+type sqliteSink struct {
+	db   *sql.DB
+	stmt *sql.Stmt
+}
+
+func newSQLiteSink(path string) (*sqliteSink, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := addRunIDColumn(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	stmt, err := db.Prepare(`INSERT INTO results (timestamp, run_id, repo, pr_url, issue_key, jira_status, expected_status, mismatch) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteSink{
+		db:   db,
+		stmt: stmt,
+	}, nil
+}
+
+// addRunIDColumn adds the run_id column to the databases that were created
+// before it was introduced.
+func addRunIDColumn(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('results')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == "run_id" {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE results ADD COLUMN run_id TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+func (s *sqliteSink) Write(r result) error {
+	_, err := s.stmt.Exec(r.Timestamp, r.RunID, r.Repo, r.PullRequestURL, r.IssueKey, r.JiraStatus, r.ExpectedStatus, r.Mismatch)
+	return err
+}
+
+// Abort closes the database, the results are already stored as they are
+// written.
+func (s *sqliteSink) Abort(reason string) error {
+	return s.Close()
+}
+
+func (s *sqliteSink) Close() error {
+	if err := s.stmt.Close(); err != nil {
+		s.db.Close()
+		return err
+	}
+	return s.db.Close()
+}
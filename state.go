@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// state is persisted between runs in the file specified by -state-file.
+type state struct {
+	// Bootstrap tracks the progress of -bootstrap per repository.
+	Bootstrap map[string]*bootstrapProgress `json:"bootstrap,omitempty"`
+
+	// Results are the last known results per pull request and issue for
+	// -deltas-only.
+	Results map[string]result `json:"results,omitempty"`
+}
+
+type bootstrapProgress struct {
+	// NextPage is the next page of pull requests to process.
+	NextPage int `json:"nextPage"`
+
+	// Done is true when all pull requests are processed.
+	Done bool `json:"done"`
+}
+
+// loadState reads the state from the file. If the file doesn't exist, an
+// empty state is returned.
+func loadState(path string) (*state, error) {
+	s := &state{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// save atomically writes the state to the file.
+func (s *state) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v32/github"
+)
+
+// processPullRequestRefs links the pull requests that are listed in r as
+// owner/repo#number, one per line. The result for each line is printed to w
+// as tab-separated values: the reference, ok, skipped or error, and the issue
+// keys or the error message. It returns false if any line failed.
+func processPullRequestRefs(ctx context.Context, jiraClient *jira.Client, githubClient *github.Client, keyRegexp *regexp.Regexp, r io.Reader, w io.Writer) bool {
+	ok := true
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		ref := strings.TrimSpace(scanner.Text())
+		if ref == "" || strings.HasPrefix(ref, "#") {
+			continue
+		}
+
+		owner, repo, number, err := parseIssueRef(ref)
+		if err != nil {
+			fmt.Fprintf(w, "%s\terror\t%v\n", ref, err)
+			ok = false
+			continue
+		}
+
+		pr, _, err := githubClient.PullRequests.Get(ctx, owner, repo, number)
+		if err != nil {
+			fmt.Fprintf(w, "%s\terror\tunable to get the pull request: %v\n", ref, err)
+			ok = false
+			continue
+		}
+
+		if isExcluded(pr) {
+			fmt.Fprintf(w, "%s\tskipped\texcluded\n", ref)
+			continue
+		}
+
+		issueKeys := pullRequestIssueKeys(ctx, githubClient, pr, keyRegexp)
+		if len(issueKeys) == 0 {
+			fmt.Fprintf(w, "%s\tskipped\tno issues are referenced\n", ref)
+			continue
+		}
+
+		permissionErrorsBefore := len(permissionErrors)
+		errs := linkPullRequestToIssueKeys(ctx, jiraClient, githubClient, pr, issueKeys)
+		var failed, skipped []string
+		for _, issueKey := range issueKeys {
+			switch err := errs[issueKey]; {
+			case errors.Is(err, errLinkSkipped):
+				skipped = append(skipped, fmt.Sprintf("%s: %v", issueKey, err))
+			case err != nil:
+				failed = append(failed, fmt.Sprintf("%s: %v", issueKey, err))
+			}
+		}
+		if len(permissionErrors) > permissionErrorsBefore {
+			failed = append(failed, "insufficient permissions: "+strings.Join(permissionErrors[permissionErrorsBefore:], "; "))
+		}
+		switch {
+		case len(failed) > 0:
+			fmt.Fprintf(w, "%s\terror\t%s\n", ref, strings.Join(failed, "; "))
+			ok = false
+		case len(skipped) > 0:
+			fmt.Fprintf(w, "%s\tskipped\t%s\n", ref, strings.Join(skipped, "; "))
+		default:
+			fmt.Fprintf(w, "%s\tok\t%s\n", ref, strings.Join(issueKeys, ","))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fatal(err)
+	}
+
+	printPermissionErrors()
+	printPlannedChanges()
+	return ok
+}
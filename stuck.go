@@ -0,0 +1,59 @@
+package main
+
+import (
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"k8s.io/klog/v2"
+)
+
+// warnedStuckIssues prevents warning about the same stuck issue for each of
+// its pull requests.
+var warnedStuckIssues = map[string]bool{}
+
+// timeInStatus returns how long the issue has been in its current status. It
+// needs the changelog of the issue, so the issues are fetched with it when
+// -max-review-time is set. Issues that never changed their status are in it
+// since they were created.
+func timeInStatus(issue *jira.Issue) (time.Duration, bool) {
+	var since time.Time
+	if issue.Changelog != nil {
+		for _, history := range issue.Changelog.Histories {
+			for _, item := range history.Items {
+				if item.Field != "status" || item.ToString != issue.Fields.Status.Name {
+					continue
+				}
+				created, err := history.CreatedTime()
+				if err != nil {
+					klog.V(2).Infof("%s: unable to parse the time of the changelog entry %s: %v", issue.Key, history.Id, err)
+					continue
+				}
+				if created.After(since) {
+					since = created
+				}
+			}
+		}
+	}
+	if since.IsZero() {
+		since = time.Time(issue.Fields.Created)
+	}
+	if since.IsZero() {
+		return 0, false
+	}
+	return time.Since(since), true
+}
+
+// checkStuckInReview warns about the issue if it has been in a review status
+// for longer than -max-review-time.
+func checkStuckInReview(issue *jira.Issue) {
+	d, ok := timeInStatus(issue)
+	if !ok {
+		return
+	}
+	klog.V(3).Infof("%s has been %s for %s", issue.Key, issue.Fields.Status.Name, d.Round(time.Hour))
+
+	if d > *maxReviewTime && !warnedStuckIssues[issue.Key] {
+		ruleWarningf(ruleStuckInReview, "%s has been %s for %s, longer than %s", issue.Key, issue.Fields.Status.Name, d.Round(time.Hour), *maxReviewTime)
+		warnedStuckIssues[issue.Key] = true
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"k8s.io/klog/v2"
+)
+
+// The markers are set from -comment-marker.
+var (
+	trackingStartMarker = "<!-- github-jira-integration:awaiting-review:start -->"
+	trackingEndMarker   = "<!-- github-jira-integration:awaiting-review:end -->"
+)
+
+// trackingIssueRef is the issue from -tracking-issue. It's parsed at startup,
+// so that a typo doesn't waste the whole run.
+var trackingIssueRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// parseIssueRef parses references like owner/repo#123.
+func parseIssueRef(ref string) (owner string, repo string, number int, err error) {
+	i := strings.LastIndex(ref, "#")
+	if i == -1 {
+		return "", "", 0, fmt.Errorf("invalid reference %q, want owner/repo#number", ref)
+	}
+
+	parts := strings.Split(ref[:i], "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", 0, fmt.Errorf("invalid reference %q, want owner/repo#number", ref)
+	}
+
+	number, err = strconv.Atoi(ref[i+1:])
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid reference %q, want owner/repo#number: %w", ref, err)
+	}
+
+	return parts[0], parts[1], number, nil
+}
+
+// renderTrackingSection renders the list of pull requests that are awaiting
+// review between the markers.
+func renderTrackingSection(lines []string) string {
+	var b strings.Builder
+	b.WriteString(trackingStartMarker + "\n")
+	b.WriteString("### Awaiting review\n\n")
+	if len(lines) == 0 {
+		b.WriteString("Nothing is awaiting review.\n")
+	}
+	for _, line := range lines {
+		b.WriteString("- " + line + "\n")
+	}
+	fmt.Fprintf(&b, "\n_Updated at %s._\n", time.Now().UTC().Format(time.RFC3339))
+	b.WriteString(trackingEndMarker)
+	return b.String()
+}
+
+// replaceTrackingSection replaces the text between the markers in the body,
+// so that the rest of the body is preserved. If there are no markers, the
+// section is appended.
+func replaceTrackingSection(body string, section string) string {
+	start := strings.Index(body, trackingStartMarker)
+	end := strings.Index(body, trackingEndMarker)
+	if start == -1 || end == -1 || end < start {
+		if body != "" && !strings.HasSuffix(body, "\n") {
+			body += "\n"
+		}
+		if body != "" {
+			body += "\n"
+		}
+		return body + section + "\n"
+	}
+	return body[:start] + section + body[end+len(trackingEndMarker):]
+}
+
+// updateTrackingIssue updates the list of pull requests that are awaiting
+// review in the body of the GitHub issue.
+func updateTrackingIssue(ctx context.Context, githubClient *github.Client, ref string, lines []string) {
+	owner, repo, number := trackingIssueRef.Owner, trackingIssueRef.Repo, trackingIssueRef.Number
+
+	issue, _, err := githubClient.Issues.Get(ctx, owner, repo, number)
+	if err != nil {
+		fatal(err)
+	}
+
+	body := replaceTrackingSection(issue.GetBody(), renderTrackingSection(lines))
+
+	klog.V(1).Infof("Updating the tracking issue %s with %d pull requests awaiting review...", ref, len(lines))
+	_, _, err = githubClient.Issues.Edit(ctx, owner, repo, number, &github.IssueRequest{
+		Body: github.String(body),
+	})
+	if err != nil {
+		fatal(err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// webhookSink posts all results as a JSON array to a webhook once the run is
+// finished. If the run is interrupted, a partialResults object is posted.
+type webhookSink struct {
+	url         string
+	contentType string
+	retries     int
+	client      *http.Client
+	results     []result
+}
+
+func newWebhookSink(url string, contentType string, retries int, transport http.RoundTripper) *webhookSink {
+	return &webhookSink{
+		url:         url,
+		contentType: contentType,
+		retries:     retries,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   *httpTimeout,
+		},
+	}
+}
+
+func (s *webhookSink) Write(r result) error {
+	s.results = append(s.results, r)
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	results := s.results
+	if results == nil {
+		results = []result{}
+	}
+
+	body, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	return s.postWithRetries(body)
+}
+
+func (s *webhookSink) Abort(reason string) error {
+	body, err := json.Marshal(partialResults{Partial: true, Reason: reason, Results: s.results})
+	if err != nil {
+		return err
+	}
+	return s.postWithRetries(body)
+}
+
+func (s *webhookSink) postWithRetries(body []byte) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = s.post(body)
+		if err == nil || attempt >= s.retries {
+			return err
+		}
+
+		delay := time.Duration(1<<uint(attempt)) * time.Second
+		klog.Warningf("Failed to post the results to the webhook, retrying in %s: %v", delay, err)
+		time.Sleep(delay)
+	}
+}
+
+func (s *webhookSink) post(body []byte) error {
+	resp, err := s.client.Post(s.url, s.contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response from %s: %s", s.url, resp.Status)
+	}
+	return nil
+}